@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zd4y/enotes/enotes"
+)
+
+func runAttach(args []string) error {
+	fs := newFlagSet("attach")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errSilent
+	}
+	name := fs.Arg(0)
+	if err := requireNote(name); err != nil {
+		return err
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	for _, src := range fs.Args()[1:] {
+		attachment, err := enotes.AttachFile(enotes.NotePath(name), src, password)
+		if err != nil {
+			return fmt.Errorf("%s: %w", src, err)
+		}
+		fmt.Printf("Attached %s as %s\n", src, attachment)
+	}
+	return nil
+}
+
+func runExtract(args []string) error {
+	fs := newFlagSet("extract")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 && fs.NArg() != 3 {
+		fs.Usage()
+		return errSilent
+	}
+	name := fs.Arg(0)
+	if err := requireNote(name); err != nil {
+		return err
+	}
+	notePath := enotes.NotePath(name)
+
+	if fs.NArg() == 1 {
+		attachments, err := enotes.ListAttachments(notePath)
+		if err != nil {
+			return err
+		}
+		if len(attachments) == 0 {
+			fmt.Fprintf(os.Stderr, "%s has no attachments\n", name)
+		}
+		for _, a := range attachments {
+			fmt.Printf("%s\t%d\t%s\n", a.Name, a.Size, a.ModTime.Format(time.RFC3339))
+		}
+		return nil
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	return enotes.ExtractAttachment(notePath, fs.Arg(1), fs.Arg(2), password)
+}
+
+func requireNote(name string) error {
+	exists, err := enotes.NoteExists(name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("note %q doesn't exist", name)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/zd4y/enotes/enotes"
+)
+
+func runBackup(args []string) error {
+	fs := newFlagSet("backup")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errSilent
+	}
+
+	out := fs.Arg(0)
+	var file *os.File
+	var w io.Writer = os.Stdout
+	if out != "-" {
+		var err error
+		file, err = os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		w = file
+	}
+
+	manifest, err := enotes.Backup(w)
+	if file != nil {
+		// The archive is only complete once the file is closed.
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(out)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Backed up", manifest.Summary())
+	return nil
+}
+
+func runRestore(args []string) error {
+	fs := newFlagSet("restore")
+	force := fs.Bool("force", false, "overwrite notes that are newer than their backup without asking")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errSilent
+	}
+
+	var r io.Reader = os.Stdin
+	if in := fs.Arg(0); in != "-" {
+		file, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	archive, err := enotes.ReadArchive(r)
+	if err != nil {
+		return fmt.Errorf("invalid backup: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "Restoring", archive.Manifest.Summary())
+
+	skipped := 0
+	restored, err := archive.Restore(func(path string) bool {
+		if *force || confirm(fmt.Sprintf("%s is newer than its backup, overwrite it?", path)) {
+			return true
+		}
+		skipped += 1
+		return false
+	})
+	fmt.Fprintf(os.Stderr, "%d files restored, %d skipped\n", restored, skipped)
+	if err != nil {
+		return err
+	}
+	if skipped > 0 {
+		return errors.New("some files were not restored, run again with -force to overwrite them")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/zd4y/enotes/enotes"
+)
+
+func runCheck(args []string) error {
+	fs := newFlagSet("check")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	report, err := enotes.Check(password)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("%d notes checked, %d ok\n", report.Notes, report.OK)
+		for _, issue := range report.Issues {
+			fmt.Printf("%-15s %s: %s\n", issue.Kind, issue.Path, issue.Detail)
+		}
+	}
+
+	if !report.Healthy() {
+		return errSilent
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+type command struct {
+	usage string
+	desc  string
+	run   func(args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"attach":  {"attach NAME FILE...", "encrypt files as attachments of a note and link them from it", runAttach},
+		"backup":  {"backup OUT", "write every encrypted note to a tar archive (- for stdout)", runBackup},
+		"check":   {"check [-json]", "verify every note decrypts and report problems", runCheck},
+		"export":  {"export [-format md|html] [-yes] DIR", "decrypt every note into plaintext files in DIR", runExport},
+		"extract": {"extract NAME [ATTACHMENT OUT]", "list the attachments of a note, or decrypt one of them to OUT", runExtract},
+		"genpass": {"genpass [-n LENGTH] [-a ALPHABET]", "print a random password, with the length and alphabet in the configuration by default", runGenpass},
+		"grep":    {"grep [-E | -fuzzy] [-l] [-json] PATTERN", "print the lines of every note matching a text, regular expression or fuzzy pattern", runGrep},
+		"import":  {"import [-format md|jex|sn|enex] [-on-conflict POLICY] [-delete-source] [-yes] PATH", "encrypt a directory of Markdown files or another app's export into notes", runImport},
+		"restore": {"restore [-force] IN", "validate a backup and restore it into the current directory", runRestore},
+		"search":  {"search [-n N] [-json] QUERY...", "list the notes containing every word of the query, best matches first", runSearch},
+		"share":   {"share [-r RECIPIENT] [-o OUT] NAME", "encrypt a note to an age public key or a passphrase as ASCII armored text", runShare},
+		"totp":    {"totp NAME", "print the current one-time passwords of the otpauth:// URIs and totp: fields in a note", runTOTP},
+	}
+}
+
+// errSilent is returned by commands that already reported why they failed.
+var errSilent = errors.New("")
+
+// Run executes the enotes subcommand named by args[0] and returns the process
+// exit code.
+func Run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(os.Stdout)
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "enotes: unknown command %q\n\n", args[0])
+		usage(os.Stderr)
+		return 2
+	}
+
+	if err := cmd.run(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if err != errSilent {
+			fmt.Fprintln(os.Stderr, "enotes:", err)
+		}
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  enotes             open the notes in the current directory")
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(w, "  enotes %s\n", cmd.usage)
+		fmt.Fprintf(w, "        %s\n", cmd.desc)
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: enotes %s\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
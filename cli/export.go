@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/zd4y/enotes/enotes"
+)
+
+func runExport(args []string) error {
+	fs := newFlagSet("export")
+	format := fs.String("format", string(enotes.ExportMarkdown), "export format: md or html")
+	yes := fs.Bool("yes", false, "don't ask for confirmation before writing plaintext files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errSilent
+	}
+	dir := fs.Arg(0)
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: this writes your notes decrypted, as plaintext files, to %s\n", dir)
+	if !*yes && !confirm("Continue?") {
+		return errors.New("export cancelled")
+	}
+
+	n, err := enotes.Export(dir, password, enotes.ExportFormat(*format))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d notes exported\n", n)
+	return nil
+}
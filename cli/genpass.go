@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/zd4y/enotes/config"
+	"github.com/zd4y/enotes/enotes"
+)
+
+func runGenpass(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("genpass")
+	length := fs.Int("n", cfg.PasswordLength, "length of the password")
+	alphabet := fs.String("a", cfg.PasswordAlphabet, "characters to pick from (letters, digits and symbols by default)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errSilent
+	}
+
+	password, err := enotes.GeneratePassword(*length, *alphabet)
+	if err != nil {
+		return err
+	}
+	fmt.Println(password)
+	return nil
+}
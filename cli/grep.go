@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/zd4y/enotes/enotes"
+	"golang.org/x/term"
+)
+
+const (
+	highlightStart = "\x1b[1;31m"
+	highlightEnd   = "\x1b[0m"
+)
+
+func runGrep(args []string) error {
+	fs := newFlagSet("grep")
+	extended := fs.Bool("E", false, "match the pattern as a regular expression (RE2 syntax)")
+	fuzzy := fs.Bool("fuzzy", false, "match lines containing the characters of the pattern in order")
+	namesOnly := fs.Bool("l", false, "only print the names of the matching notes")
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errSilent
+	}
+
+	mode := enotes.GrepText
+	switch {
+	case *extended && *fuzzy:
+		return errors.New("-E and -fuzzy can't be used together")
+	case *extended:
+		mode = enotes.GrepRegexp
+	case *fuzzy:
+		mode = enotes.GrepFuzzy
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	results, err := enotes.Grep(fs.Arg(0), mode, password)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	if len(results) == 0 {
+		return errSilent
+	}
+
+	color := term.IsTerminal(int(os.Stdout.Fd()))
+	for _, r := range results {
+		if *namesOnly {
+			fmt.Println(r.Name)
+			continue
+		}
+		for _, m := range r.Matches {
+			text := m.Text
+			if color {
+				text = highlight(m.Text, m.Ranges, highlightStart, highlightEnd)
+			}
+			fmt.Printf("%s:%d:%s\n", r.Name, m.Line, text)
+		}
+	}
+	return nil
+}
+
+// highlight surrounds the ranges of s with start and end.
+func highlight(s string, ranges [][2]int, start string, end string) string {
+	out := ""
+	last := 0
+	for _, r := range ranges {
+		out += s[last:r[0]] + start + s[r[0]:r[1]] + end
+		last = r[1]
+	}
+	return out + s[last:]
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/zd4y/enotes/enotes"
+)
+
+func runImport(args []string) error {
+	fs := newFlagSet("import")
+	format := fs.String("format", "md", "import format: md (a directory), jex (Joplin export), sn (decrypted Standard Notes backup) or enex (Evernote export)")
+	onConflict := fs.String("on-conflict", string(enotes.ConflictRename), "what to do with notes that already exist: skip, rename, overwrite or fail")
+	deleteSource := fs.Bool("delete-source", false, "securely delete the imported files after verifying their encrypted copies")
+	yes := fs.Bool("yes", false, "don't ask for confirmation before deleting the imported files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errSilent
+	}
+	src := fs.Arg(0)
+
+	policy, err := enotes.ParseConflictPolicy(*onConflict)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "md":
+	case "jex", "sn", "enex":
+		if *deleteSource {
+			return fmt.Errorf("-delete-source is only supported with -format md")
+		}
+	default:
+		return fmt.Errorf("unsupported import format %q", *format)
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	if *deleteSource && !*yes && !confirm(fmt.Sprintf("Delete the imported files from %s?", src)) {
+		return errors.New("import cancelled")
+	}
+
+	var result *enotes.ImportResult
+	switch *format {
+	case "jex":
+		result, err = enotes.ImportJoplin(src, password, policy)
+	case "sn":
+		result, err = enotes.ImportStandardNotes(src, password, policy)
+	case "enex":
+		result, err = enotes.ImportEvernote(src, password, policy)
+	default:
+		opts := enotes.ImportOptions{OnConflict: policy, DeleteSource: *deleteSource}
+		result, err = enotes.ImportDir(src, password, opts)
+	}
+	if result != nil {
+		printImportResult(result)
+	}
+	return err
+}
+
+func printImportResult(result *enotes.ImportResult) {
+	for _, warning := range result.Warnings {
+		fmt.Fprintln(os.Stderr, "warning:", warning)
+	}
+	for _, path := range result.Skipped {
+		fmt.Fprintf(os.Stderr, "skipped %s: note already exists\n", path)
+	}
+	fmt.Fprintf(os.Stderr, "%d notes imported, %d skipped", len(result.Imported), len(result.Skipped))
+	if len(result.Deleted) > 0 {
+		fmt.Fprintf(os.Stderr, ", %d source files deleted", len(result.Deleted))
+	}
+	fmt.Fprintln(os.Stderr)
+}
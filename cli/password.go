@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zd4y/enotes/enotes"
+	"golang.org/x/term"
+)
+
+// readPassword asks for the vault password on the controlling terminal, or
+// reads it from the first line of stdin when there is none, and verifies it.
+func readPassword() (string, error) {
+	exists, err := enotes.PasswordExists()
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", errors.New("no notes in the current directory, run enotes to set a password first")
+	}
+
+	password, err := promptPassword("Password: ")
+	if err != nil {
+		return "", err
+	}
+	if err := enotes.VerifyPassword(password); err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+func promptPassword(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("reading password: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, prompt)
+	password, err := term.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", fmt.Errorf("reading password: %w", err)
+	}
+	return string(password), nil
+}
+
+// confirm asks a yes or no question on the controlling terminal. It returns
+// false when there is no terminal to ask on.
+func confirm(question string) bool {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	defer tty.Close()
+
+	fmt.Fprintf(tty, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zd4y/enotes/enotes"
+)
+
+func runSearch(args []string) error {
+	fs := newFlagSet("search")
+	limit := fs.Int("n", 20, "maximum number of results, 0 for all")
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errSilent
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	results, err := enotes.Search(strings.Join(fs.Args(), " "), password)
+	if err != nil {
+		return err
+	}
+	if *limit > 0 && len(results) > *limit {
+		results = results[:*limit]
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "No notes found")
+		return errSilent
+	}
+	for _, r := range results {
+		if r.Title != "" && r.Title != r.Name {
+			fmt.Printf("%6.2f  %s (%s)\n", r.Score, r.Name, r.Title)
+		} else {
+			fmt.Printf("%6.2f  %s\n", r.Score, r.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/zd4y/enotes/enotes"
+)
+
+func runShare(args []string) error {
+	fs := newFlagSet("share")
+	recipient := fs.String("r", "", "age public key to encrypt to, asks for a passphrase when empty")
+	out := fs.String("o", "-", "file to write the armored note to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errSilent
+	}
+	name := fs.Arg(0)
+
+	if err := requireNote(name); err != nil {
+		return err
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	if *recipient != "" && !strings.HasPrefix(*recipient, "age1") {
+		return fmt.Errorf("%q is not an age public key", *recipient)
+	}
+	if *recipient == "" {
+		passphrase, err := promptPassword("Passphrase to share the note with: ")
+		if err != nil {
+			return err
+		}
+		confirmation, err := promptPassword("Confirm passphrase: ")
+		if err != nil {
+			return err
+		}
+		if passphrase != confirmation {
+			return errors.New("passphrases didn't match")
+		}
+		if passphrase == "" {
+			return errors.New("empty passphrase")
+		}
+		*recipient = passphrase
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		file, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return enotes.ShareNote(enotes.NotePath(name), password, *recipient, w)
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zd4y/enotes/enotes"
+)
+
+func runTOTP(args []string) error {
+	fs := newFlagSet("totp")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errSilent
+	}
+	name := fs.Arg(0)
+
+	if err := requireNote(name); err != nil {
+		return err
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	note, err := enotes.OpenNote(enotes.NotePath(name), password)
+	if err != nil {
+		return err
+	}
+	_, body := enotes.ParseNote(note)
+	totps, errs := enotes.NoteTOTPs(body)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, "enotes:", err)
+	}
+	if len(totps) == 0 {
+		if len(errs) == 0 {
+			fmt.Fprintf(os.Stderr, "No one-time password secrets in %s\n", name)
+		}
+		return errSilent
+	}
+
+	now := time.Now()
+	for _, totp := range totps {
+		code, remaining := totp.Code(now)
+		if len(totps) == 1 {
+			// Only the code goes to stdout, so it can be piped.
+			fmt.Println(code)
+			fmt.Fprintf(os.Stderr, "valid for %ds\n", int(remaining.Seconds()))
+		} else {
+			fmt.Printf("%s  %2ds  %s\n", code, int(remaining.Seconds()), totp.Name(name))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config holds the user preferences, read from config.json in the enotes
+// directory of the user configuration directory (for example
+// ~/.config/enotes/config.json), or from the file ENOTES_CONFIG points to.
+type Config struct {
+	// Viewer is the command used to open attachments. The path of a temporary
+	// decrypted copy of the attachment is appended to it, and the copy is
+	// removed when the command exits.
+	Viewer string `json:"viewer"`
+	// ClipboardTimeout is the number of seconds after which text copied from
+	// a note is cleared from the clipboard, or 0 to keep it.
+	ClipboardTimeout int `json:"clipboardTimeout"`
+	// PasswordLength and PasswordAlphabet are the length of the generated
+	// passwords and the characters they are made of. An empty alphabet uses
+	// letters, digits and symbols.
+	PasswordLength   int    `json:"passwordLength"`
+	PasswordAlphabet string `json:"passwordAlphabet"`
+	// Keys changes the keys of the bindings named in it, like
+	// {"edit": ["e", "ctrl+e"]}. An empty list disables the binding.
+	Keys map[string][]string `json:"keys,omitempty"`
+	// Theme is "auto", to choose the dark or light theme from the background
+	// of the terminal, "dark", "light" or "dracula".
+	Theme string `json:"theme"`
+	// MarkdownStyle is the name of a glamour style or the path to a glamour
+	// JSON style file, used instead of the one of the theme to render notes.
+	MarkdownStyle string `json:"markdownStyle"`
+	// Colors changes the colors of the theme named in it, like
+	// {"title": "#7D56F4"}, as ANSI color numbers or hex colors.
+	Colors map[string]string `json:"colors,omitempty"`
+	// Sort is the order of the notes list, by "title", "modified", "created"
+	// or "size", reversed if SortDescending is set. Changing it from the
+	// notes list saves them in the configuration file.
+	Sort           string `json:"sort"`
+	SortDescending bool   `json:"sortDescending"`
+}
+
+func Default() *Config {
+	return &Config{
+		ClipboardTimeout: 30,
+		PasswordLength:   20,
+		Theme:            "auto",
+		Sort:             "title",
+	}
+}
+
+func Path() (string, error) {
+	if path := os.Getenv("ENOTES_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "enotes", "config.json"), nil
+}
+
+// Load reads the configuration file. A missing file is not an error, the
+// default configuration is returned instead.
+func Load() (*Config, error) {
+	c := Default()
+	path, err := Path()
+	if err != nil {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// SaveSort writes the order of the notes list in c to the configuration file,
+// creating it if needed. The rest of the file is kept as it is, so settings
+// left to their defaults aren't written.
+func SaveSort(c *Config) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	fields := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if fields["sort"], err = json.Marshal(c.Sort); err != nil {
+		return err
+	}
+	if fields["sortDescending"], err = json.Marshal(c.SortDescending); err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
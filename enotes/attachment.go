@@ -0,0 +1,184 @@
+package enotes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Attachments are arbitrary files encrypted separately from the note, in a
+// directory next to it: the attachments of "recipes/pizza.md.age" are kept in
+// "recipes/pizza.files/" as "<file name>.age". Notes refer to them with links
+// like [dough.pdf](<attachment:dough.pdf>).
+
+const (
+	attachmentsDirSuffix = ".files"
+	attachmentSuffix     = ".age"
+	AttachmentScheme     = "attachment:"
+)
+
+type Attachment struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// AttachmentLink returns the Markdown link a note uses to refer to the
+// attachment called name.
+func AttachmentLink(name string) string {
+	return "[" + name + "](<" + AttachmentScheme + name + ">)"
+}
+
+// AttachFile encrypts the file at srcPath as a new attachment of the note at
+// notePath and adds a link to it at the end of the note. It returns the name
+// of the attachment, which differs from the file name if the note already had
+// an attachment with that name.
+func AttachFile(notePath string, srcPath string, password string) (string, error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	note, err := decrypt(notePath, password)
+	if err != nil {
+		return "", err
+	}
+
+	name, err := newAttachmentName(notePath, filepath.Base(srcPath))
+	if err != nil {
+		return "", err
+	}
+	if err := saveAttachment(notePath, name, src, password); err != nil {
+		return "", err
+	}
+
+	content := note.String()
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += "\n" + AttachmentLink(name) + "\n"
+	return name, saveNote(notePath, []byte(content), password)
+}
+
+// ListAttachments returns the attachments of the note at notePath, sorted by
+// name.
+func ListAttachments(notePath string) ([]Attachment, error) {
+	entries, err := os.ReadDir(attachmentsDir(notePath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var attachments []Attachment
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), attachmentSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, Attachment{
+			Name:    strings.TrimSuffix(entry.Name(), attachmentSuffix),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i].Name < attachments[j].Name
+	})
+	return attachments, nil
+}
+
+// ExtractAttachment decrypts the attachment called name to dstPath, which must
+// not exist.
+func ExtractAttachment(notePath string, name string, dstPath string, password string) error {
+	if !validAttachmentName(name) {
+		return fmt.Errorf("invalid attachment name %q", name)
+	}
+	dst, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := decryptTo(dst, attachmentPath(notePath, name), password); err != nil {
+		dst.Close()
+		os.Remove(dstPath)
+		return err
+	}
+	return dst.Close()
+}
+
+// OpenAttachment decrypts the attachment called name to a temporary file, to
+// open it with another program. The returned function removes it.
+func OpenAttachment(notePath string, name string, password string) (string, func() error, error) {
+	dir, err := os.MkdirTemp("", tempFilePrefix+"attachment-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() error {
+		return os.RemoveAll(dir)
+	}
+
+	tempPath := filepath.Join(dir, name)
+	if err := ExtractAttachment(notePath, name, tempPath, password); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tempPath, cleanup, nil
+}
+
+func saveAttachment(notePath string, name string, src io.Reader, password string) error {
+	if err := os.MkdirAll(attachmentsDir(notePath), 0o700); err != nil {
+		return err
+	}
+	return encryptFrom(src, attachmentPath(notePath, name), password)
+}
+
+func newAttachmentName(notePath string, name string) (string, error) {
+	if !validAttachmentName(name) {
+		return "", fmt.Errorf("invalid attachment name %q", name)
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for i := 2; ; i++ {
+		exists, err := pathExists(attachmentPath(notePath, candidate))
+		if err != nil || !exists {
+			return candidate, err
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+}
+
+func validAttachmentName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+func attachmentsDir(notePath string) string {
+	return NoteName(notePath) + attachmentsDirSuffix
+}
+
+func attachmentPath(notePath string, name string) string {
+	return filepath.Join(attachmentsDir(notePath), name+attachmentSuffix)
+}
+
+// isAttachment reports whether path is an encrypted attachment of a note.
+// Attachments can have any name, including the ones of notes, so they are
+// told apart by their directory. A directory is only the attachments of a note
+// if the note exists, otherwise it is a notebook whose name ends in ".files".
+func isAttachment(path string) bool {
+	dir := filepath.Dir(path)
+	if !strings.HasSuffix(dir, attachmentsDirSuffix) || !strings.HasSuffix(path, attachmentSuffix) {
+		return false
+	}
+	exists, _ := pathExists(strings.TrimSuffix(dir, attachmentsDirSuffix) + noteSuffix)
+	return exists
+}
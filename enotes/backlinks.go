@@ -0,0 +1,119 @@
+package enotes
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The wiki links of every note are kept in an encrypted index, so finding the
+// notes that link to another one doesn't need to decrypt the whole vault. The
+// index stores the link targets as written, since what they resolve to changes
+// as notes are created.
+
+const linkIndexFileName = ".enotes-links.age"
+
+type linkIndex struct {
+	Notes map[string]linkIndexEntry `json:"notes"`
+}
+
+type linkIndexEntry struct {
+	ModTime time.Time `json:"modTime"`
+	Links   []string  `json:"links"`
+}
+
+var linkIndexCache struct {
+	sync.Mutex
+	index    *linkIndex
+	password string
+}
+
+// Backlinks returns the names of the notes that have a wiki link to the note
+// called name, sorted alphabetically.
+func Backlinks(name string, password string) ([]string, error) {
+	defer lockIndexes()()
+
+	notes, err := refreshIndexes(password)
+	if err != nil {
+		return nil, err
+	}
+	index := loadLinkIndex(password)
+
+	exists := func(name string) bool {
+		return notes[name]
+	}
+	var backlinks []string
+	for source, entry := range index.Notes {
+		if source == name {
+			continue
+		}
+		for _, target := range entry.Links {
+			if resolved, _ := resolveWikiLink(source, target, exists); resolved == name {
+				backlinks = append(backlinks, source)
+				break
+			}
+		}
+	}
+	sort.Strings(backlinks)
+	return backlinks, nil
+}
+
+// indexNoteLinks updates the wiki links of the note at path, whose contents
+// were just saved, in the link index.
+func indexNoteLinks(path string, content []byte, password string) error {
+	linkIndexCache.Lock()
+	defer linkIndexCache.Unlock()
+
+	index := loadLinkIndex(password)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	index.set(NoteName(path), info.ModTime(), string(content))
+	return writeEncryptedJSON(linkIndexFileName, index, password)
+}
+
+// loadLinkIndex returns the cached link index, reading it from the vault the
+// first time. linkIndexCache must be locked.
+func loadLinkIndex(password string) *linkIndex {
+	if linkIndexCache.index != nil && linkIndexCache.password == password {
+		return linkIndexCache.index
+	}
+	index := &linkIndex{}
+	if err := readEncryptedJSON(linkIndexFileName, index, password); err != nil {
+		// A damaged index is built again from the notes.
+		index = &linkIndex{}
+	}
+	if index.Notes == nil {
+		index.Notes = map[string]linkIndexEntry{}
+	}
+	linkIndexCache.index = index
+	linkIndexCache.password = password
+	return index
+}
+
+func (index *linkIndex) modTime(name string) (time.Time, bool) {
+	entry, ok := index.Notes[name]
+	return entry.ModTime, ok
+}
+
+func (index *linkIndex) set(name string, modTime time.Time, content string) {
+	_, body := ParseNote(content)
+	index.Notes[name] = linkIndexEntry{
+		ModTime: modTime,
+		Links:   wikiLinkTargets(body),
+	}
+}
+
+func (index *linkIndex) remove(name string) {
+	delete(index.Notes, name)
+}
+
+func (index *linkIndex) names() []string {
+	names := make([]string, 0, len(index.Notes))
+	for name := range index.Notes {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,344 @@
+package enotes
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	manifestName    = "manifest.json"
+	manifestVersion = 1
+)
+
+type Manifest struct {
+	Version int            `json:"version"`
+	Created time.Time      `json:"created"`
+	Notes   int            `json:"notes"`
+	Files   []ManifestFile `json:"files"`
+}
+
+type ManifestFile struct {
+	Path    string      `json:"path"`
+	Size    int64       `json:"size"`
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"modTime"`
+	SHA256  string      `json:"sha256"`
+}
+
+// Backup writes a tar archive of every encrypted file in the vault to w. The
+// first entry is a manifest with the checksum of every other entry. The files
+// are copied as they are, so the archive is as safe to store as the vault.
+func Backup(w io.Writer) (*Manifest, error) {
+	paths, err := backupPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		Version: manifestVersion,
+		Created: time.Now().UTC(),
+		Files:   make([]ManifestFile, 0, len(paths)),
+	}
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		sum, err := fileChecksum(p)
+		if err != nil {
+			return nil, err
+		}
+		if IsNote(p) && !isAttachment(p) {
+			manifest.Notes += 1
+		}
+		manifest.Files = append(manifest.Files, ManifestFile{
+			Path:    filepath.ToSlash(p),
+			Size:    info.Size(),
+			Mode:    info.Mode().Perm(),
+			ModTime: info.ModTime().UTC(),
+			SHA256:  sum,
+		})
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	tw := tar.NewWriter(w)
+	err = tw.WriteHeader(&tar.Header{
+		Name:    manifestName,
+		Mode:    0o600,
+		Size:    int64(len(manifestBytes)),
+		ModTime: manifest.Created,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(manifestBytes); err != nil {
+		return nil, err
+	}
+
+	for _, f := range manifest.Files {
+		err := tw.WriteHeader(&tar.Header{
+			Name:    f.Path,
+			Mode:    int64(f.Mode),
+			Size:    f.Size,
+			ModTime: f.ModTime,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := copyFileTo(tw, filepath.FromSlash(f.Path)); err != nil {
+			return nil, err
+		}
+	}
+
+	return manifest, tw.Close()
+}
+
+// Archive is a backup read into memory whose contents have been validated
+// against its manifest.
+type Archive struct {
+	Manifest Manifest
+	files    map[string][]byte
+}
+
+// ReadArchive reads a backup created by Backup, verifying that it contains
+// exactly the files listed in its manifest with matching checksums.
+func ReadArchive(r io.Reader) (*Archive, error) {
+	tr := tar.NewReader(r)
+
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	if hdr.Name != manifestName {
+		return nil, errors.New("not an enotes backup: missing manifest")
+	}
+
+	a := &Archive{files: map[string][]byte{}}
+	if err := json.NewDecoder(tr).Decode(&a.Manifest); err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	if a.Manifest.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", a.Manifest.Version)
+	}
+
+	expected := map[string]ManifestFile{}
+	for _, f := range a.Manifest.Files {
+		if !isSafeArchivePath(f.Path) {
+			return nil, fmt.Errorf("unsafe path in manifest: %q", f.Path)
+		}
+		expected[f.Path] = f
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		f, ok := expected[hdr.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s: not listed in the manifest", hdr.Name)
+		}
+		if _, dup := a.files[hdr.Name]; dup {
+			return nil, fmt.Errorf("%s: duplicated in the archive", hdr.Name)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(content)
+		if int64(len(content)) != f.Size || hex.EncodeToString(sum[:]) != f.SHA256 {
+			return nil, fmt.Errorf("%s: checksum mismatch", hdr.Name)
+		}
+		a.files[hdr.Name] = content
+	}
+
+	for p := range expected {
+		if _, ok := a.files[p]; !ok {
+			return nil, fmt.Errorf("%s: listed in the manifest but missing from the archive", p)
+		}
+	}
+	return a, nil
+}
+
+// Conflicts returns the files in the archive that exist in the vault with a
+// more recent modification time than the backed up copy.
+func (a *Archive) Conflicts() ([]string, error) {
+	var conflicts []string
+	for _, f := range a.Manifest.Files {
+		if !isRestored(f.Path) || f.Path == passwordFileName {
+			continue
+		}
+		info, err := os.Stat(filepath.FromSlash(f.Path))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if info.ModTime().After(f.ModTime) {
+			conflicts = append(conflicts, f.Path)
+		}
+	}
+	return conflicts, nil
+}
+
+// Restore writes the archived files into the vault. Files that are newer in the
+// vault than in the archive are only replaced when overwrite returns true for
+// them. It returns the number of files written.
+//
+// Nothing is restored into a vault with a different password file, since
+// replacing it would lock out the notes already in the vault. The indexes are
+// not restored either, they are updated from the restored notes the next time
+// they are used.
+func (a *Archive) Restore(overwrite func(path string) bool) (int, error) {
+	if err := a.checkPasswordFile(); err != nil {
+		return 0, err
+	}
+	passwordExists, err := PasswordExists()
+	if err != nil {
+		return 0, err
+	}
+	conflicts, err := a.Conflicts()
+	if err != nil {
+		return 0, err
+	}
+	skip := map[string]bool{}
+	for _, p := range conflicts {
+		if overwrite == nil || !overwrite(p) {
+			skip[p] = true
+		}
+	}
+
+	restored := 0
+	for _, f := range a.Manifest.Files {
+		if skip[f.Path] || !isRestored(f.Path) || (f.Path == passwordFileName && passwordExists) {
+			continue
+		}
+		dst := filepath.FromSlash(f.Path)
+		if dir := filepath.Dir(dst); dir != "." {
+			if err := os.MkdirAll(dir, 0o700); err != nil {
+				return restored, err
+			}
+		}
+		if err := os.WriteFile(dst, a.files[f.Path], f.Mode.Perm()); err != nil {
+			return restored, err
+		}
+		if err := os.Chtimes(dst, f.ModTime, f.ModTime); err != nil {
+			return restored, err
+		}
+		restored += 1
+	}
+	return restored, nil
+}
+
+// checkPasswordFile returns an error if the vault has a password file that
+// differs from the one in the archive.
+func (a *Archive) checkPasswordFile() error {
+	archived, ok := a.files[passwordFileName]
+	if !ok {
+		return nil
+	}
+	current, err := os.ReadFile(passwordFileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, archived) {
+		return errors.New("the backup has a different password file than this vault, restore it into an empty directory instead")
+	}
+	return nil
+}
+
+// isRestored reports whether the archived file at p is written by Restore,
+// which is every file but the indexes.
+func isRestored(p string) bool {
+	return p != linkIndexFileName && p != searchIndexFileName
+}
+
+// backupPaths returns the notes, attachments and vault files under the current
+// directory.
+func backupPaths() ([]string, error) {
+	var paths []string
+	err := walkVault(func(p string) error {
+		if IsNote(p) || isVaultFile(p) || isAttachment(p) {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	sort.Strings(paths)
+	return paths, err
+}
+
+func isSafeArchivePath(p string) bool {
+	return p != "" &&
+		p != manifestName &&
+		!path.IsAbs(p) &&
+		!strings.Contains(p, "\\") &&
+		path.Clean(p) == p &&
+		p != ".." &&
+		!strings.HasPrefix(p, "../")
+}
+
+func fileChecksum(path string) (string, error) {
+	h := sha256.New()
+	if err := copyFileTo(h, path); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func copyFileTo(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// Summary describes the archive contents in a single line.
+func (m *Manifest) Summary() string {
+	var size int64
+	for _, f := range m.Files {
+		size += f.Size
+	}
+	return fmt.Sprintf("%d notes, %d files, %s, created %s",
+		m.Notes, len(m.Files), ByteCount(size), m.Created.Local().Format(time.Stamp))
+}
+
+// ByteCount formats n bytes with a binary unit, like "1.5 KiB".
+func ByteCount(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,149 @@
+package enotes
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type archiveEntry struct {
+	name    string
+	content string
+}
+
+// testArchive returns a tar archive with a manifest listing files, followed by
+// entries.
+func testArchive(t *testing.T, files []ManifestFile, entries []archiveEntry) []byte {
+	t.Helper()
+	manifest, err := json.Marshal(Manifest{Version: manifestVersion, Files: files})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range append([]archiveEntry{{manifestName, string(manifest)}}, entries...) {
+		err := tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0o600, Size: int64(len(e.content))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func manifestFile(path, content string) ManifestFile {
+	sum := sha256.Sum256([]byte(content))
+	return ManifestFile{
+		Path:   path,
+		Size:   int64(len(content)),
+		Mode:   0o600,
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+}
+
+func TestReadArchive(t *testing.T) {
+	note := manifestFile("work/todo.md.age", "encrypted")
+	tests := []struct {
+		name    string
+		files   []ManifestFile
+		entries []archiveEntry
+		err     string
+	}{
+		{
+			name:    "valid",
+			files:   []ManifestFile{note},
+			entries: []archiveEntry{{"work/todo.md.age", "encrypted"}},
+		},
+		{
+			name:    "traversal",
+			files:   []ManifestFile{manifestFile("../todo.md.age", "encrypted")},
+			entries: []archiveEntry{{"../todo.md.age", "encrypted"}},
+			err:     "unsafe path",
+		},
+		{
+			name:    "absolute path",
+			files:   []ManifestFile{manifestFile("/tmp/todo.md.age", "encrypted")},
+			entries: []archiveEntry{{"/tmp/todo.md.age", "encrypted"}},
+			err:     "unsafe path",
+		},
+		{
+			name:    "bad checksum",
+			files:   []ManifestFile{note},
+			entries: []archiveEntry{{"work/todo.md.age", "modified!"}},
+			err:     "checksum mismatch",
+		},
+		{
+			name:    "unlisted file",
+			files:   []ManifestFile{note},
+			entries: []archiveEntry{{"work/todo.md.age", "encrypted"}, {"other.md.age", "x"}},
+			err:     "not listed in the manifest",
+		},
+		{
+			name:  "missing file",
+			files: []ManifestFile{note},
+			err:   "missing from the archive",
+		},
+	}
+	for _, test := range tests {
+		archive, err := ReadArchive(bytes.NewReader(testArchive(t, test.files, test.entries)))
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		case test.err == "" && string(archive.files[note.Path]) != "encrypted":
+			t.Errorf("%s: got contents %q", test.name, archive.files[note.Path])
+		}
+	}
+}
+
+func TestReadArchiveMissingManifest(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "todo.md.age", Mode: 0o600, Size: 1})
+	tw.Write([]byte("x"))
+	tw.Close()
+
+	_, err := ReadArchive(&buf)
+	if err == nil || !strings.Contains(err.Error(), "missing manifest") {
+		t.Errorf("got error %v, want a missing manifest", err)
+	}
+}
+
+func TestIsSafeArchivePath(t *testing.T) {
+	tests := []struct {
+		path string
+		safe bool
+	}{
+		{"todo.md.age", true},
+		{"work/todo.md.age", true},
+		{"work/todo.files/a.pdf.age", true},
+		{".enotes-password.age", true},
+		{"..todo.md.age", true},
+		{"", false},
+		{manifestName, false},
+		{"/etc/passwd", false},
+		{"..", false},
+		{"../todo.md.age", false},
+		{"work/../../todo.md.age", false},
+		{"work/../todo.md.age", false},
+		{"./todo.md.age", false},
+		{"work//todo.md.age", false},
+		{`work\todo.md.age`, false},
+		{`..\todo.md.age`, false},
+	}
+	for _, test := range tests {
+		if safe := isSafeArchivePath(test.path); safe != test.safe {
+			t.Errorf("isSafeArchivePath(%q) = %v, want %v", test.path, safe, test.safe)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package enotes
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+)
+
+const (
+	ageIntro     = "age-encryption.org/v1"
+	ageNonceSize = 16
+
+	// orphanedTempAge is how long a temporary file has to be left unmodified
+	// to be reported, so the files of editors still open aren't.
+	orphanedTempAge = 24 * time.Hour
+)
+
+// IssueKind describes what is wrong with a file reported by Check.
+type IssueKind string
+
+const (
+	IssueCorrupted     IssueKind = "corrupted"
+	IssueTruncated     IssueKind = "truncated"
+	IssueWrongPassword IssueKind = "wrong-password"
+	IssueNotAge        IssueKind = "not-age"
+	IssueOrphanedTemp  IssueKind = "orphaned-temp"
+	IssueUnknownFile   IssueKind = "unknown-file"
+)
+
+type Issue struct {
+	Path   string    `json:"path"`
+	Kind   IssueKind `json:"kind"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+type CheckReport struct {
+	Notes  int     `json:"notes"`
+	OK     int     `json:"ok"`
+	Issues []Issue `json:"issues"`
+}
+
+func (r *CheckReport) Healthy() bool {
+	return len(r.Issues) == 0
+}
+
+// Check walks the vault verifying that every note decrypts with password, and
+// reports anything that doesn't belong in it, including plaintext temporary
+// files left behind by interrupted editor sessions.
+func Check(password string) (*CheckReport, error) {
+	identity, err := age.NewScryptIdentity(password)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CheckReport{Issues: []Issue{}}
+	err = walkVault(func(path string) error {
+		name := filepath.Base(path)
+		switch {
+		case isVaultFile(name) || isAttachment(path):
+			if issue := checkAgeFile(path, identity); issue != nil {
+				report.Issues = append(report.Issues, *issue)
+			}
+		case IsNote(name):
+			report.Notes += 1
+			if issue := checkAgeFile(path, identity); issue != nil {
+				report.Issues = append(report.Issues, *issue)
+			} else {
+				report.OK += 1
+			}
+		default:
+			report.Issues = append(report.Issues, Issue{
+				Path:   path,
+				Kind:   IssueUnknownFile,
+				Detail: "not a note",
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orphans, err := orphanedTempFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range orphans {
+		report.Issues = append(report.Issues, Issue{
+			Path:   path,
+			Kind:   IssueOrphanedTemp,
+			Detail: "plaintext copy of a note or attachment, left by an interrupted editor or viewer",
+		})
+	}
+
+	return report, nil
+}
+
+// isVaultFile reports whether name is one of the encrypted files enotes keeps
+// next to the notes, like the password file.
+func isVaultFile(name string) bool {
+	return strings.HasPrefix(name, ".enotes-") && strings.HasSuffix(name, ".age")
+}
+
+func checkAgeFile(path string, identity age.Identity) *Issue {
+	issue := func(kind IssueKind, detail string) *Issue {
+		return &Issue{Path: path, Kind: kind, Detail: detail}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return issue(IssueCorrupted, err.Error())
+	}
+	defer file.Close()
+
+	br := bufio.NewReader(file)
+	intro, err := br.Peek(len(ageIntro))
+	if err != nil {
+		if len(intro) == 0 {
+			return issue(IssueTruncated, "empty file")
+		}
+		if strings.HasPrefix(ageIntro, string(intro)) {
+			return issue(IssueTruncated, "file ends inside the age header")
+		}
+	}
+	if string(intro) != ageIntro {
+		return issue(IssueNotAge, "missing age header")
+	}
+
+	if err := skipAgeHeader(br); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return issue(IssueTruncated, "file ends before the encrypted contents")
+		}
+		return issue(IssueCorrupted, err.Error())
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return issue(IssueCorrupted, err.Error())
+	}
+
+	r, err := age.Decrypt(bufio.NewReader(file), identity)
+	if err != nil {
+		if _, ok := err.(*age.NoIdentityMatchError); ok {
+			return issue(IssueWrongPassword, "not encrypted with the vault password")
+		}
+		return issue(IssueCorrupted, err.Error())
+	}
+
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return issue(IssueTruncated, err.Error())
+		}
+		return issue(IssueCorrupted, err.Error()+" (truncated or modified)")
+	}
+	return nil
+}
+
+// skipAgeHeader reads the header of an age file and the nonce that starts its
+// payload. age doesn't wrap the errors of reading them, so this tells files
+// that end too early apart from corrupted ones.
+func skipAgeHeader(r *bufio.Reader) error {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(line, "--- ") {
+			break
+		}
+	}
+	_, err := io.ReadFull(r, make([]byte, ageNonceSize))
+	return err
+}
+
+func orphanedTempFiles() ([]string, error) {
+	dir := os.TempDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, tempFilePrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < orphanedTempAge {
+			continue
+		}
+		orphans = append(orphans, filepath.Join(dir, name))
+	}
+	return orphans, nil
+}
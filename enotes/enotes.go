@@ -2,11 +2,15 @@ package enotes
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"filippo.io/age"
 )
@@ -15,10 +19,15 @@ const (
 	noteExt          = ".md"
 	noteSuffix       = noteExt + ".age"
 	passwordFileName = ".enotes-password.age"
+	tempFilePrefix   = "enotes-"
 )
 
 var IncorrectPasswordError = errors.New("incorrect password")
 
+// IndexError is wrapped by the errors of saving a note that was saved, but
+// couldn't be added to the indexes.
+var IndexError = errors.New("the note was saved but the indexes couldn't be updated")
+
 func PasswordExists() (bool, error) {
 	return pathExists(passwordFileName)
 }
@@ -36,6 +45,7 @@ func VerifyPassword(password string) error {
 	if err != nil {
 		return err
 	}
+	defer passwordFile.Close()
 	identity, err := age.NewScryptIdentity(password)
 	if err != nil {
 		return err
@@ -56,6 +66,10 @@ func NoteExists(name string) (bool, error) {
 	return pathExists(path)
 }
 
+func NotePath(name string) string {
+	return name + noteSuffix
+}
+
 func NoteName(path string) string {
 	return strings.TrimSuffix(path, noteSuffix)
 }
@@ -70,7 +84,7 @@ func OpenNote(path string, password string) (string, error) {
 
 func CreateNote(name string, password string) (string, func() error, error) {
 	path := name + noteSuffix
-	prefix := name + ".*" + noteExt
+	prefix := tempFilePrefix + filepath.Base(name) + ".*" + noteExt
 
 	tempFileName, done, err := useTempFile(
 		prefix,
@@ -82,16 +96,19 @@ func CreateNote(name string, password string) (string, func() error, error) {
 
 	return tempFileName, func() error {
 		err := encryptFile(tempFileName, path, password)
-		if err != nil {
+		if err != nil && !errors.Is(err, IndexError) {
+			return err
+		}
+		if err := done(); err != nil {
 			return err
 		}
-		return done()
+		return err
 	}, nil
 }
 
 func EditNote(path string, password string) (string, func() error, error) {
 	name := NoteName(path)
-	prefix := name + ".*" + noteExt
+	prefix := tempFilePrefix + filepath.Base(name) + ".*" + noteExt
 
 	noteBytes, err := decrypt(path, password)
 	if err != nil {
@@ -111,34 +128,88 @@ func EditNote(path string, password string) (string, func() error, error) {
 
 	return tempFileName, func() error {
 		err := encryptFile(tempFileName, path, password)
-		if err != nil {
+		if err != nil && !errors.Is(err, IndexError) {
+			return err
+		}
+		if err := done(); err != nil {
 			return err
 		}
-		return done()
+		return err
 	}, err
 }
 
+// WriteNote encrypts content into the note called name, creating its notebook
+// if needed and replacing the note if it already exists.
+func WriteNote(name string, content []byte, password string) error {
+	return saveAndIndexNote(name+noteSuffix, content, time.Time{}, password)
+}
+
+// saveAndIndexNote encrypts content into the note at path and updates the
+// indexes with it. A modTime that isn't zero is set on the note before it is
+// indexed, so the indexes don't take it for a note changed outside enotes.
+func saveAndIndexNote(path string, content []byte, modTime time.Time, password string) error {
+	if err := saveNote(path, content, password); err != nil {
+		return err
+	}
+	if !modTime.IsZero() {
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			return err
+		}
+	}
+	return indexNote(path, content, password)
+}
+
+// indexNote updates the indexes with the contents of the note at path, which
+// were just saved. The indexes only speed up searches, they are updated from
+// the note the next time they are used if this fails, so the error wraps
+// IndexError for callers to tell it apart from failing to save the note.
+func indexNote(path string, content []byte, password string) error {
+	err := indexNoteLinks(path, content, password)
+	if wordsErr := indexNoteWords(path, content, password); err == nil {
+		err = wordsErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", IndexError, err)
+	}
+	return nil
+}
+
+func saveNote(path string, content []byte, password string) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+	return encrypt(content, path, password)
+}
+
 func decrypt(path string, password string) (*bytes.Buffer, error) {
+	out := &bytes.Buffer{}
+	if err := decryptTo(out, path, password); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decryptTo(w io.Writer, path string, password string) error {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer file.Close()
 
 	identity, err := age.NewScryptIdentity(password)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	r, err := age.Decrypt(file, identity)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	out := &bytes.Buffer{}
-	if _, err := io.Copy(out, r); err != nil {
-		return nil, err
-	}
-	return out, nil
+	_, err = io.Copy(w, r)
+	return err
 }
 
 func encryptFile(srcPath string, dstPath string, password string) error {
@@ -147,10 +218,14 @@ func encryptFile(srcPath string, dstPath string, password string) error {
 		return err
 	}
 
-	return encrypt(srcBytes, dstPath, password)
+	return saveAndIndexNote(dstPath, srcBytes, time.Time{}, password)
 }
 
 func encrypt(content []byte, dstPath string, password string) error {
+	return encryptFrom(bytes.NewReader(content), dstPath, password)
+}
+
+func encryptFrom(src io.Reader, dstPath string, password string) error {
 	recipient, err := age.NewScryptRecipient(password)
 	if err != nil {
 		return err
@@ -160,18 +235,43 @@ func encrypt(content []byte, dstPath string, password string) error {
 	if err != nil {
 		return err
 	}
+	defer dstFile.Close()
 
 	w, err := age.Encrypt(dstFile, recipient)
 	if err != nil {
 		return err
 	}
 
-	_, err = w.Write(content)
+	_, err = io.Copy(w, src)
 	if err != nil {
 		return err
 	}
 
-	return w.Close()
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return dstFile.Close()
+}
+
+// readEncryptedJSON decrypts the JSON file at path into v, leaving v as it is
+// if the file doesn't exist.
+func readEncryptedJSON(path string, v interface{}, password string) error {
+	content, err := decrypt(path, password)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content.Bytes(), v)
+}
+
+func writeEncryptedJSON(path string, v interface{}, password string) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return encrypt(content, path, password)
 }
 
 func useTempFile(prefix string, manipulateTempFile func(*os.File) error) (string, func() error, error) {
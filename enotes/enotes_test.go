@@ -0,0 +1,48 @@
+package enotes
+
+import (
+	"os"
+	"testing"
+)
+
+const testPassword = "password"
+
+// useTempVault changes the working directory to a new empty vault for the
+// rest of the test.
+func useTempVault(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	// The indexes are cached in memory for the vault they were read from.
+	linkIndexCache.index = nil
+	searchIndexCache.index = nil
+	undecryptableNotes.modTimes = nil
+}
+
+func TestWriteNote(t *testing.T) {
+	useTempVault(t)
+	if err := WriteNote("work/todo", []byte("# Todo\n\nBuy milk"), testPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := OpenNote(NotePath("work/todo"), testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "# Todo\n\nBuy milk" {
+		t.Errorf("got content %q", content)
+	}
+	results, err := Search("milk", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Name != "work/todo" {
+		t.Errorf("got search results %v, want work/todo", results)
+	}
+}
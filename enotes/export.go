@@ -0,0 +1,131 @@
+package enotes
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type ExportFormat string
+
+const (
+	ExportMarkdown ExportFormat = "md"
+	ExportHTML     ExportFormat = "html"
+)
+
+// Export decrypts every note into dir, keeping the notebook structure and the
+// modification time of each note. Attachments are decrypted next to their
+// note. The exported files are plaintext. HTML exports also get an index.html
+// page linking to every note. It returns the number of notes exported.
+func Export(dir string, password string, format ExportFormat) (int, error) {
+	var ext string
+	var render func(name string, content string) ([]byte, error)
+	switch format {
+	case ExportMarkdown:
+		ext = noteExt
+		render = func(name string, content string) ([]byte, error) {
+			return []byte(content), nil
+		}
+	case ExportHTML:
+		ext = htmlExt
+		render = renderHTMLNote
+	default:
+		return 0, fmt.Errorf("unsupported export format %q", format)
+	}
+
+	inVault, err := isInsideVault(dir)
+	if err != nil {
+		return 0, err
+	}
+	if inVault {
+		return 0, errors.New("refusing to export plaintext notes inside the notes directory")
+	}
+
+	notes, err := ListNotes()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, path := range notes {
+		info, err := os.Stat(path)
+		if err != nil {
+			return i, err
+		}
+		content, err := OpenNote(path, password)
+		if err != nil {
+			return i, fmt.Errorf("%s: %w", path, err)
+		}
+		out, err := render(NoteName(path), content)
+		if err != nil {
+			return i, fmt.Errorf("%s: %w", path, err)
+		}
+
+		dst := filepath.Join(dir, NoteName(path)+ext)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+			return i, err
+		}
+		if err := os.WriteFile(dst, out, 0o600); err != nil {
+			return i, err
+		}
+		if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+			return i, err
+		}
+		if err := exportAttachments(path, filepath.Join(dir, NoteName(path)+attachmentsDirSuffix), password); err != nil {
+			return i, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if format == ExportHTML {
+		names := make([]string, len(notes))
+		for i, path := range notes {
+			names[i] = NoteName(path)
+		}
+		index := filepath.Join(dir, "index"+htmlExt)
+		if err := os.WriteFile(index, renderHTMLIndex(names), 0o600); err != nil {
+			return len(notes), err
+		}
+	}
+	return len(notes), nil
+}
+
+func exportAttachments(notePath string, dir string, password string) error {
+	attachments, err := ListAttachments(notePath)
+	if err != nil || len(attachments) == 0 {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		dst, err := os.OpenFile(filepath.Join(dir, a.Name), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+		err = decryptTo(dst, attachmentPath(notePath, a.Name), password)
+		if closeErr := dst.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isInsideVault(dir string) (bool, error) {
+	vault, err := filepath.Abs(".")
+	if err != nil {
+		return false, err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(vault, abs)
+	if err != nil {
+		return false, err
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
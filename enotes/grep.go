@@ -0,0 +1,245 @@
+package enotes
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// GrepMode is how Grep matches the pattern against the lines of the notes.
+type GrepMode string
+
+const (
+	// GrepText matches the pattern as plain text, ignoring case unless it has
+	// uppercase letters.
+	GrepText GrepMode = "text"
+	// GrepRegexp matches the pattern as a regular expression (RE2 syntax).
+	GrepRegexp GrepMode = "regexp"
+	// GrepFuzzy matches lines containing the characters of the pattern in
+	// order, close to each other.
+	GrepFuzzy GrepMode = "fuzzy"
+)
+
+const (
+	// grepMaxMatches is the maximum number of lines returned for every note.
+	grepMaxMatches = 100
+	// decryptWorkers is the number of notes decrypted at the same time. Every
+	// one takes about 256 MiB for scrypt, so it doesn't grow with the CPUs.
+	decryptWorkers = 3
+)
+
+type GrepMatch struct {
+	// Line is the line number in the note contents, starting at 1.
+	Line int    `json:"line"`
+	Text string `json:"text"`
+	// Ranges are the start and end byte offsets of the matches in Text.
+	Ranges [][2]int `json:"ranges"`
+}
+
+type GrepResult struct {
+	Name    string      `json:"name"`
+	Matches []GrepMatch `json:"matches"`
+	// Score is the number of matching lines, or the score of the best line
+	// for fuzzy matches.
+	Score int `json:"score"`
+}
+
+// Grep searches the contents of every note for pattern. Unlike Search it
+// decrypts the notes, which are kept in memory while the program runs so later
+// searches only decrypt the notes that changed. Results are sorted by name,
+// or best matches first in fuzzy mode.
+func Grep(pattern string, mode GrepMode, password string) ([]GrepResult, error) {
+	match, err := grepMatcher(pattern, mode)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := decryptNotes(password)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []GrepResult{}
+	for name, content := range notes {
+		result := GrepResult{Name: name}
+		for i, line := range strings.Split(content, "\n") {
+			ranges, score, ok := match(line)
+			if !ok {
+				continue
+			}
+			if mode == GrepFuzzy {
+				if len(result.Matches) == 0 || score > result.Score {
+					result.Score = score
+				}
+			} else {
+				result.Score++
+			}
+			if len(result.Matches) < grepMaxMatches {
+				result.Matches = append(result.Matches, GrepMatch{Line: i + 1, Text: line, Ranges: ranges})
+			}
+		}
+		if len(result.Matches) > 0 {
+			results = append(results, result)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if mode == GrepFuzzy && results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+// grepMatcher returns a function reporting whether a line matches pattern,
+// with the ranges that matched and the score of the match.
+func grepMatcher(pattern string, mode GrepMode) (func(line string) ([][2]int, int, bool), error) {
+	if pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+	switch mode {
+	case GrepText:
+		if strings.IndexFunc(pattern, unicode.IsUpper) == -1 {
+			pattern = "(?i)" + regexp.QuoteMeta(pattern)
+		} else {
+			pattern = regexp.QuoteMeta(pattern)
+		}
+		fallthrough
+	case GrepRegexp:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return func(line string) ([][2]int, int, bool) {
+			var ranges [][2]int
+			for _, loc := range re.FindAllStringIndex(line, -1) {
+				if loc[0] != loc[1] {
+					ranges = append(ranges, [2]int{loc[0], loc[1]})
+				}
+			}
+			return ranges, len(ranges), len(ranges) > 0
+		}, nil
+	case GrepFuzzy:
+		// Every line has the characters of short patterns somewhere, so the
+		// matched characters have to be close to each other.
+		maxSpan := 4 * utf8.RuneCountInString(pattern)
+		return func(line string) ([][2]int, int, bool) {
+			// fuzzy takes a NUL for the end of the line and panics after it,
+			// replacing it with another byte keeps the offsets.
+			matches := fuzzy.Find(pattern, []string{strings.ReplaceAll(line, "\x00", " ")})
+			if len(matches) == 0 {
+				return nil, 0, false
+			}
+			indexes := matches[0].MatchedIndexes
+			if utf8.RuneCountInString(line[indexes[0]:indexes[len(indexes)-1]]) >= maxSpan {
+				return nil, 0, false
+			}
+			return indexRanges(line, indexes), matches[0].Score, true
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown search mode %q", mode)
+}
+
+// indexRanges joins the byte offsets of the runes in s at indexes into ranges.
+func indexRanges(s string, indexes []int) [][2]int {
+	var ranges [][2]int
+	for _, i := range indexes {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		if n := len(ranges); n > 0 && ranges[n-1][1] == i {
+			ranges[n-1][1] = i + size
+			continue
+		}
+		ranges = append(ranges, [2]int{i, i + size})
+	}
+	return ranges
+}
+
+var noteCache struct {
+	sync.Mutex
+	password string
+	notes    map[string]cachedNote
+}
+
+type cachedNote struct {
+	modTime time.Time
+	content string
+}
+
+// decryptNotes returns the contents of every note by name, decrypting the
+// notes that changed since they were cached in parallel. Like in the indexes,
+// notes that can't be decrypted are left out.
+func decryptNotes(password string) (map[string]string, error) {
+	noteCache.Lock()
+	defer noteCache.Unlock()
+
+	if noteCache.notes == nil || noteCache.password != password {
+		noteCache.notes = map[string]cachedNote{}
+		noteCache.password = password
+	}
+
+	paths, err := ListNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	type job struct {
+		path    string
+		modTime time.Time
+	}
+	var stale []job
+	existing := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		existing[p] = true
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if cached, ok := noteCache.notes[p]; !ok || !cached.modTime.Equal(info.ModTime()) {
+			stale = append(stale, job{p, info.ModTime()})
+		}
+	}
+	for p := range noteCache.notes {
+		if !existing[p] {
+			delete(noteCache.notes, p)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	jobs := make(chan job)
+	for i := 0; i < decryptWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				content, err := decrypt(j.path, password)
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				noteCache.notes[j.path] = cachedNote{j.modTime, content.String()}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, j := range stale {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+
+	notes := make(map[string]string, len(noteCache.notes))
+	for p, cached := range noteCache.notes {
+		notes[NoteName(p)] = cached.content
+	}
+	return notes, nil
+}
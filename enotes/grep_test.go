@@ -0,0 +1,87 @@
+package enotes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGrepMatcher(t *testing.T) {
+	tests := []struct {
+		pattern string
+		mode    GrepMode
+		line    string
+		ranges  [][2]int
+		ok      bool
+	}{
+		{"milk", GrepText, "Buy Milk and milk", [][2]int{{4, 8}, {13, 17}}, true},
+		{"Milk", GrepText, "Buy Milk and milk", [][2]int{{4, 8}}, true},
+		{"a.c", GrepText, "abc", nil, false},
+		{"a.c", GrepText, "a.c", [][2]int{{0, 3}}, true},
+		{"a.c", GrepRegexp, "abc", [][2]int{{0, 3}}, true},
+		{`\d+`, GrepRegexp, "call 555 1234", [][2]int{{5, 8}, {9, 13}}, true},
+		{"x*", GrepRegexp, "abc", nil, false},
+		{"bmk", GrepFuzzy, "buy milk", [][2]int{{0, 1}, {4, 5}, {7, 8}}, true},
+		{"bk", GrepFuzzy, "b                    k", nil, false},
+		{"bk", GrepFuzzy, "b\x00k\x00", [][2]int{{0, 1}, {2, 3}}, true},
+		{"zz", GrepFuzzy, "buy milk", nil, false},
+		{"ño", GrepFuzzy, "el año", [][2]int{{4, 7}}, true},
+	}
+	for _, test := range tests {
+		match, err := grepMatcher(test.pattern, test.mode)
+		if err != nil {
+			t.Errorf("%s %q: %v", test.mode, test.pattern, err)
+			continue
+		}
+		ranges, _, ok := match(test.line)
+		if ok != test.ok || !reflect.DeepEqual(ranges, test.ranges) {
+			t.Errorf("%s %q on %q: got %v, %v, want %v, %v",
+				test.mode, test.pattern, test.line, ranges, ok, test.ranges, test.ok)
+		}
+	}
+
+	for _, test := range []struct {
+		pattern string
+		mode    GrepMode
+	}{{"", GrepText}, {"(", GrepRegexp}, {"milk", "glob"}} {
+		if _, err := grepMatcher(test.pattern, test.mode); err == nil {
+			t.Errorf("%s %q: expected an error", test.mode, test.pattern)
+		}
+	}
+}
+
+func TestGrep(t *testing.T) {
+	useTempVault(t)
+	notes := map[string]string{
+		"groceries":  "# Groceries\nMilk\nBread\nmilk chocolate",
+		"work/todo":  "Call the bank\nBuy milk for the office",
+		"work/ideas": "Nothing to see",
+	}
+	for name, content := range notes {
+		if err := WriteNote(name, []byte(content), testPassword); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := Grep("milk", GrepText, testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GrepResult{
+		{
+			Name: "groceries",
+			Matches: []GrepMatch{
+				{Line: 2, Text: "Milk", Ranges: [][2]int{{0, 4}}},
+				{Line: 4, Text: "milk chocolate", Ranges: [][2]int{{0, 4}}},
+			},
+			Score: 2,
+		},
+		{
+			Name:    "work/todo",
+			Matches: []GrepMatch{{Line: 2, Text: "Buy milk for the office", Ranges: [][2]int{{4, 8}}}},
+			Score:   1,
+		},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("got %+v, want %+v", results, want)
+	}
+}
@@ -0,0 +1,184 @@
+package enotes
+
+import (
+	"bytes"
+	"html"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+const htmlExt = ".html"
+
+const htmlStyle = `body{max-width:46em;margin:2em auto;padding:0 1em;font:16px/1.6 -apple-system,"Segoe UI",Helvetica,Arial,sans-serif;color:#24292f}
+a{color:#0969da}
+pre,code{font-family:ui-monospace,Menlo,Consolas,monospace;background:#f6f8fa;border-radius:4px}
+pre{padding:1em;overflow:auto}
+code{padding:.1em .3em}
+pre code{padding:0}
+table{border-collapse:collapse}
+td,th{border:1px solid #d0d7de;padding:.3em .6em}
+blockquote{margin:0;padding-left:1em;border-left:4px solid #d0d7de;color:#57606a}
+nav{margin-bottom:2em;font-size:.9em}
+h2.notebook{margin-top:1.5em}`
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(
+		parser.WithInlineParsers(util.Prioritized(wikiLinkParser{}, 199)),
+		parser.WithASTTransformers(util.Prioritized(noteLinkTransformer{}, 100)),
+	),
+)
+
+var noteNameKey = parser.NewContextKey()
+
+// noteLinkTransformer makes relative links to other notes point to their
+// exported HTML page, and links to attachments point to their exported file.
+// Wiki links become links to the page of the note they point to, or struck
+// through text if it doesn't exist.
+type noteLinkTransformer struct{}
+
+func (noteLinkTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	name, _ := pc.Get(noteNameKey).(string)
+	var wikiLinks []*wikiLinkNode
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Link:
+			n.Destination = []byte(noteLinkToHTML(name, string(n.Destination)))
+		case *ast.Image:
+			n.Destination = []byte(noteLinkToHTML(name, string(n.Destination)))
+		case *wikiLinkNode:
+			wikiLinks = append(wikiLinks, n)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, n := range wikiLinks {
+		var replacement ast.Node
+		if target, ok := ResolveWikiLink(name, n.target); ok {
+			link := ast.NewLink()
+			link.Destination = []byte(wikiLinkToHTML(name, target))
+			replacement = link
+		} else {
+			replacement = east.NewStrikethrough()
+		}
+		replacement.AppendChild(replacement, ast.NewString([]byte(n.label)))
+		n.Parent().ReplaceChild(n.Parent(), n, replacement)
+	}
+}
+
+// wikiLinkToHTML returns the relative URL of the exported page of the note
+// called target from the one of the note called from. Every segment is
+// escaped, since note names can have characters with a meaning in URLs.
+func wikiLinkToHTML(from string, target string) string {
+	rel, err := filepath.Rel(filepath.Dir(from), target)
+	if err != nil {
+		rel = target
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	href := strings.Join(segments, "/") + htmlExt
+	if strings.Contains(segments[0], ":") {
+		// Otherwise the name would be taken for a URL scheme.
+		href = "./" + href
+	}
+	return href
+}
+
+func noteLinkToHTML(name string, dest string) string {
+	if attachment := strings.TrimPrefix(dest, AttachmentScheme); attachment != dest {
+		dir := path.Base(filepath.ToSlash(name)) + attachmentsDirSuffix
+		return (&url.URL{Path: dir + "/" + attachment}).String()
+	}
+
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || path.IsAbs(u.Path) {
+		return dest
+	}
+	switch {
+	case strings.HasSuffix(u.Path, noteSuffix):
+		u.Path = strings.TrimSuffix(u.Path, noteSuffix) + htmlExt
+	case strings.HasSuffix(u.Path, noteExt):
+		u.Path = strings.TrimSuffix(u.Path, noteExt) + htmlExt
+	default:
+		return dest
+	}
+	return u.String()
+}
+
+func renderHTMLNote(name string, content string) ([]byte, error) {
+	meta, content := ParseNote(content)
+	ctx := parser.NewContext()
+	ctx.Set(noteNameKey, name)
+	var body bytes.Buffer
+	if err := markdown.Convert([]byte(content), &body, parser.WithContext(ctx)); err != nil {
+		return nil, err
+	}
+
+	title := meta.Title
+	if title == "" {
+		title = path.Base(filepath.ToSlash(name))
+	}
+	root := strings.Repeat("../", strings.Count(filepath.ToSlash(name), "/"))
+	var page bytes.Buffer
+	writeHTMLHead(&page, title)
+	page.WriteString(`<nav><a href="` + root + `index.html">&larr; All notes</a></nav>` + "\n")
+	page.Write(body.Bytes())
+	page.WriteString("</body>\n</html>\n")
+	return page.Bytes(), nil
+}
+
+// renderHTMLIndex renders a page linking to every exported note, grouped by
+// notebook.
+func renderHTMLIndex(names []string) []byte {
+	names = append([]string(nil), names...)
+	for i := range names {
+		names[i] = filepath.ToSlash(names[i])
+	}
+	sort.Slice(names, func(i, j int) bool {
+		di, dj := path.Dir(names[i]), path.Dir(names[j])
+		if di != dj {
+			return di == "." || (dj != "." && di < dj)
+		}
+		return names[i] < names[j]
+	})
+
+	var page bytes.Buffer
+	writeHTMLHead(&page, "Notes")
+	page.WriteString("<h1>Notes</h1>\n")
+
+	notebook := ""
+	page.WriteString("<ul>\n")
+	for _, name := range names {
+		if dir := path.Dir(name); dir != "." && dir != notebook {
+			notebook = dir
+			page.WriteString("</ul>\n<h2 class=\"notebook\">" + html.EscapeString(notebook) + "</h2>\n<ul>\n")
+		}
+		href := (&url.URL{Path: name + htmlExt}).String()
+		page.WriteString(`<li><a href="` + html.EscapeString(href) + `">` + html.EscapeString(path.Base(name)) + "</a></li>\n")
+	}
+	page.WriteString("</ul>\n</body>\n</html>\n")
+	return page.Bytes()
+}
+
+func writeHTMLHead(b *bytes.Buffer, title string) {
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	b.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	b.WriteString("<style>" + htmlStyle + "</style>\n</head>\n<body>\n")
+}
@@ -0,0 +1,43 @@
+package enotes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWikiLinkToHTML(t *testing.T) {
+	tests := []struct {
+		from   string
+		target string
+		href   string
+	}{
+		{"todo", "ideas", "ideas.html"},
+		{"todo", "Meeting: 2024", "./Meeting:%202024.html"},
+		{"work/todo", "work/Meeting: 2024", "./Meeting:%202024.html"},
+		{"work/todo", "ideas", "../ideas.html"},
+		{"todo", "work/plans #1?", "work/plans%20%231%3F.html"},
+		{"todo", "100%", "100%25.html"},
+	}
+	for _, test := range tests {
+		if href := wikiLinkToHTML(test.from, test.target); href != test.href {
+			t.Errorf("wikiLinkToHTML(%q, %q) = %q, want %q", test.from, test.target, href, test.href)
+		}
+	}
+}
+
+func TestRenderHTMLNoteWikiLinks(t *testing.T) {
+	useTempVault(t)
+	if err := WriteNote("Meeting: 2024", []byte("# Meeting"), testPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := renderHTMLNote("todo", "See [[Meeting: 2024]] and [[missing]].")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<a href="./Meeting:%202024.html">Meeting: 2024</a>`, "<del>missing</del>"} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("page doesn't contain %s:\n%s", want, page)
+		}
+	}
+}
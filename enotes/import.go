@@ -0,0 +1,194 @@
+package enotes
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ConflictPolicy decides what happens when an imported note has the same name
+// as an existing one.
+type ConflictPolicy string
+
+const (
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictRename    ConflictPolicy = "rename"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	ConflictFail      ConflictPolicy = "fail"
+)
+
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case ConflictSkip, ConflictRename, ConflictOverwrite, ConflictFail:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (use skip, rename, overwrite or fail)", s)
+}
+
+type ImportOptions struct {
+	OnConflict ConflictPolicy
+	// DeleteSource securely deletes every imported file once its encrypted
+	// copy has been verified to decrypt to the same content.
+	DeleteSource bool
+}
+
+type ImportResult struct {
+	Imported []string `json:"imported"`
+	Skipped  []string `json:"skipped"`
+	Deleted  []string `json:"deleted"`
+	Warnings []string `json:"warnings"`
+}
+
+// ImportDir encrypts every Markdown file under dir into the vault, keeping its
+// folder as notebook and its modification time.
+func ImportDir(dir string, password string, opts ImportOptions) (*ImportResult, error) {
+	inVault, err := isInsideVault(dir)
+	if err != nil {
+		return nil, err
+	}
+	if inVault {
+		return nil, errors.New("refusing to import files from inside the notes directory")
+	}
+
+	result := &ImportResult{}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		hidden := strings.HasPrefix(d.Name(), ".")
+		if d.IsDir() {
+			if path != dir && hidden {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if hidden || !d.Type().IsRegular() || filepath.Ext(path) != noteExt {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		name, err := importNote(strings.TrimSuffix(rel, noteExt), content, info.ModTime(), password, opts.OnConflict)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if name == "" {
+			result.Skipped = append(result.Skipped, path)
+			return nil
+		}
+		result.Imported = append(result.Imported, name)
+
+		if opts.DeleteSource {
+			if err := verifyNote(name, content, password); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if err := secureDelete(path); err != nil {
+				return err
+			}
+			result.Deleted = append(result.Deleted, path)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// importNote writes content as a new note called name, applying policy if the
+// note already exists. It returns the name the note was saved with, or an
+// empty string if it was skipped.
+func importNote(name string, content []byte, modTime time.Time, password string, policy ConflictPolicy) (string, error) {
+	name, err := resolveConflict(name, policy)
+	if err != nil || name == "" {
+		return "", err
+	}
+
+	// The indexes are updated from the note the next time they are used if
+	// adding it to them fails, which is better than stopping the import.
+	err = saveAndIndexNote(name+noteSuffix, content, modTime, password)
+	if err != nil && !errors.Is(err, IndexError) {
+		return "", err
+	}
+	return name, nil
+}
+
+func resolveConflict(name string, policy ConflictPolicy) (string, error) {
+	exists, err := NoteExists(name)
+	if err != nil || !exists {
+		return name, err
+	}
+
+	switch policy {
+	case ConflictSkip:
+		return "", nil
+	case ConflictOverwrite:
+		return name, nil
+	case ConflictRename:
+		for i := 2; ; i++ {
+			candidate := fmt.Sprintf("%s (%d)", name, i)
+			exists, err := NoteExists(candidate)
+			if err != nil {
+				return "", err
+			}
+			if !exists {
+				return candidate, nil
+			}
+		}
+	default:
+		return "", fmt.Errorf("note %q already exists", name)
+	}
+}
+
+func verifyNote(name string, content []byte, password string) error {
+	decrypted, err := decrypt(name+noteSuffix, password)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(decrypted.Bytes(), content) {
+		return errors.New("encrypted note doesn't match the imported file")
+	}
+	return nil
+}
+
+// secureDelete overwrites the file at path with random bytes before removing
+// it. This is best effort: journaling and copy on write filesystems or SSDs may
+// still keep the original content somewhere.
+func secureDelete(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := io.CopyN(file, rand.Reader, info.Size()); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
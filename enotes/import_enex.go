@@ -0,0 +1,389 @@
+package enotes
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const enexTimeLayout = "20060102T150405Z"
+
+type enexNote struct {
+	Title     string         `xml:"title"`
+	Content   string         `xml:"content"`
+	Created   string         `xml:"created"`
+	Updated   string         `xml:"updated"`
+	Tags      []string       `xml:"tag"`
+	Resources []enexResource `xml:"resource"`
+}
+
+type enexResource struct {
+	Data     string `xml:"data"`
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+}
+
+// enexAttachment is a resource decoded to be saved as an attachment.
+type enexAttachment struct {
+	name string
+	data []byte
+}
+
+// enexAttachments decodes the resources of a note, returning them along with
+// a map from the hash en-media elements use to refer to them to their name.
+func enexAttachments(resources []enexResource) ([]enexAttachment, map[string]string, []string) {
+	var attachments []enexAttachment
+	var problems []string
+	names := map[string]bool{}
+	media := map[string]string{}
+	for i, r := range resources {
+		data, err := base64.StdEncoding.DecodeString(spaceRegexp.ReplaceAllString(r.Data, ""))
+		if err != nil || len(data) == 0 {
+			problems = append(problems, fmt.Sprintf("attachment %d (%s) has no valid data", i+1, r.Mime))
+			continue
+		}
+
+		name := strings.TrimSpace(filepath.Base(strings.ReplaceAll(r.FileName, "\\", "/")))
+		if name == "" || name == "." || name == "/" {
+			name = fmt.Sprintf("attachment-%d", i+1)
+			if exts, _ := mime.ExtensionsByType(r.Mime); len(exts) > 0 {
+				name += exts[0]
+			}
+		}
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		for n := 2; names[name]; n++ {
+			name = fmt.Sprintf("%s (%d)%s", base, n, ext)
+		}
+		names[name] = true
+
+		sum := md5.Sum(data)
+		media[hex.EncodeToString(sum[:])] = name
+		attachments = append(attachments, enexAttachment{name, data})
+	}
+	return attachments, media, problems
+}
+
+// ImportEvernote imports the notes of an Evernote export file (ENEX),
+// converting their contents to Markdown and saving their title, tags and
+// timestamps in their metadata. Resources are saved as attachments, content
+// that can't be represented in Markdown is reported as a warning.
+func ImportEvernote(enexPath string, password string, policy ConflictPolicy) (*ImportResult, error) {
+	file, err := os.Open(enexPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result := &ImportResult{}
+	d := xml.NewDecoder(file)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("reading %s: %w", enexPath, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		var note enexNote
+		if err := d.DecodeElement(&note, &start); err != nil {
+			return result, fmt.Errorf("reading %s: %w", enexPath, err)
+		}
+
+		name := noteNameFromTitle(note.Title)
+		attachments, media, problems := enexAttachments(note.Resources)
+		for _, problem := range problems {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", name, problem))
+		}
+		body, unsupported, err := enmlToMarkdown(note.Content, media)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, what := range unsupported {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s not imported", name, what))
+		}
+
+		meta := Metadata{Title: note.Title, Tags: note.Tags}
+		meta.Created, _ = time.Parse(enexTimeLayout, note.Created)
+		meta.Updated, _ = time.Parse(enexTimeLayout, note.Updated)
+		modTime := meta.Updated
+		if modTime.IsZero() {
+			modTime = meta.Created
+		}
+
+		saved, err := importNote(name, []byte(FormatNote(meta, body)), modTime, password, policy)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", name, err)
+		}
+		if saved == "" {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+		result.Imported = append(result.Imported, saved)
+
+		for _, a := range attachments {
+			if err := saveAttachment(NotePath(saved), a.name, bytes.NewReader(a.data), password); err != nil {
+				return result, fmt.Errorf("%s: %w", saved, err)
+			}
+		}
+	}
+	return result, nil
+}
+
+var (
+	spaceRegexp      = regexp.MustCompile(`[ \t\r\n]+`)
+	newlinesRegexp   = regexp.MustCompile(`\n{3,}`)
+	blankLinesRegexp = regexp.MustCompile(`\n{2,}`)
+)
+
+type enmlConverter struct {
+	pre         int
+	media       map[string]string
+	unsupported []string
+}
+
+// enmlToMarkdown converts the ENML (Evernote's XHTML dialect) contents of a
+// note to Markdown. media maps the hash of every resource to the name of the
+// attachment it was saved as. It also returns a description of the elements
+// that were left out.
+func enmlToMarkdown(enml string, media map[string]string) (string, []string, error) {
+	doc, err := html.Parse(strings.NewReader(enml))
+	if err != nil {
+		return "", nil, err
+	}
+
+	c := &enmlConverter{media: media}
+	out := c.render(doc)
+
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	out = newlinesRegexp.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	out = strings.TrimSpace(out)
+	if out != "" {
+		out += "\n"
+	}
+	return out, c.unsupported, nil
+}
+
+func (c *enmlConverter) children(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		out := c.render(child)
+		// Every div is a line of its own.
+		if child.Type == html.ElementNode && child.Data == "div" && b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString(out)
+	}
+	return b.String()
+}
+
+func (c *enmlConverter) render(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		if c.pre > 0 {
+			return n.Data
+		}
+		return spaceRegexp.ReplaceAllString(n.Data, " ")
+	case html.ElementNode:
+	default:
+		return c.children(n)
+	}
+
+	switch n.Data {
+	case "head", "title", "style", "script":
+		return ""
+	case "br":
+		return "\n"
+	case "hr":
+		return "\n\n---\n\n"
+	case "p":
+		return block(c.children(n))
+	case "div":
+		if strings.Contains(attr(n, "style"), "-en-codeblock") {
+			return c.codeBlock(n)
+		}
+		if c.pre > 0 {
+			return strings.TrimSuffix(c.children(n), "\n") + "\n"
+		}
+		return strings.TrimSpace(c.children(n)) + "\n"
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.Data[1] - '0')
+		return block(strings.Repeat("#", level) + " " + strings.TrimSpace(strings.ReplaceAll(c.children(n), "\n", " ")))
+	case "b", "strong":
+		return wrapInline(c.children(n), "**")
+	case "i", "em":
+		return wrapInline(c.children(n), "*")
+	case "s", "strike", "del":
+		return wrapInline(c.children(n), "~~")
+	case "code", "tt":
+		if c.pre > 0 {
+			return c.children(n)
+		}
+		return wrapInline(c.children(n), "`")
+	case "pre":
+		return c.codeBlock(n)
+	case "a":
+		text := strings.TrimSpace(c.children(n))
+		href := attr(n, "href")
+		switch {
+		case href == "":
+			return text
+		case text == "" || text == href:
+			return "<" + href + ">"
+		}
+		return "[" + text + "](" + href + ")"
+	case "img":
+		return "![" + attr(n, "alt") + "](" + attr(n, "src") + ")"
+	case "blockquote":
+		inner := strings.TrimSpace(c.children(n))
+		return block("> " + strings.ReplaceAll(inner, "\n", "\n> "))
+	case "ul", "ol":
+		return c.list(n)
+	case "table":
+		return c.table(n)
+	// en-todo and en-media are written as self-closing tags, which the HTML
+	// parser doesn't support for unknown elements, so whatever follows them
+	// ends up as their children.
+	case "en-todo":
+		if attr(n, "checked") == "true" {
+			return "- [x] " + c.children(n)
+		}
+		return "- [ ] " + c.children(n)
+	case "en-media":
+		name, ok := c.media[attr(n, "hash")]
+		if !ok {
+			c.unsupported = append(c.unsupported, "missing attachment "+attr(n, "hash"))
+			return c.children(n)
+		}
+		link := AttachmentLink(name)
+		if strings.HasPrefix(attr(n, "type"), "image/") {
+			link = "!" + link
+		}
+		return link + c.children(n)
+	case "en-crypt":
+		c.unsupported = append(c.unsupported, "encrypted text")
+		return ""
+	}
+	return c.children(n)
+}
+
+func (c *enmlConverter) codeBlock(n *html.Node) string {
+	c.pre++
+	code := strings.Trim(c.children(n), "\n")
+	c.pre--
+	return block("```\n" + code + "\n```")
+}
+
+func (c *enmlConverter) list(n *html.Node) string {
+	var b strings.Builder
+	i := 1
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.Data != "li" {
+			continue
+		}
+		marker := "- "
+		if n.Data == "ol" {
+			marker = strconv.Itoa(i) + ". "
+			i++
+		}
+		item := strings.TrimSpace(blankLinesRegexp.ReplaceAllString(c.children(li), "\n"))
+		// Todos already render their own list marker.
+		if strings.HasPrefix(item, "- [") {
+			marker = ""
+		}
+		indent := strings.Repeat(" ", len(marker))
+		b.WriteString(marker + strings.ReplaceAll(item, "\n", "\n"+indent) + "\n")
+	}
+	return block(b.String())
+}
+
+func (c *enmlConverter) table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if child.Data != "tr" {
+				walk(child)
+				continue
+			}
+			var row []string
+			for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+					text := strings.TrimSpace(spaceRegexp.ReplaceAllString(c.children(cell), " "))
+					row = append(row, strings.ReplaceAll(text, "|", "\\|"))
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString(strings.Repeat("| --- ", columns) + "|\n")
+		}
+	}
+	return block(b.String())
+}
+
+func block(s string) string {
+	return "\n\n" + strings.TrimSpace(s) + "\n\n"
+}
+
+// wrapInline surrounds s with delim, keeping the surrounding spaces outside so
+// the result is still valid Markdown emphasis.
+func wrapInline(s string, delim string) string {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return s
+	}
+	start := strings.Index(s, trimmed)
+	return s[:start] + delim + trimmed + delim + s[start+len(trimmed):]
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package enotes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestENMLToMarkdown(t *testing.T) {
+	media := map[string]string{"abc": "photo.png", "def": "report.pdf"}
+	tests := []struct {
+		name        string
+		enml        string
+		markdown    string
+		unsupported []string
+	}{
+		{
+			name:     "todos",
+			enml:     `<en-note><div><en-todo checked="true"/>Buy milk</div><div><en-todo/>Bread</div></en-note>`,
+			markdown: "- [x] Buy milk\n- [ ] Bread\n",
+		},
+		{
+			name:     "media",
+			enml:     `<en-note><div>See <en-media hash="abc" type="image/png"/> and <en-media hash="def" type="application/pdf"/> here</div></en-note>`,
+			markdown: "See ![photo.png](<attachment:photo.png>) and [report.pdf](<attachment:report.pdf>) here\n",
+		},
+		{
+			name:        "missing media",
+			enml:        `<en-note><div>Photo: <en-media hash="zzz" type="image/png"/></div></en-note>`,
+			markdown:    "Photo:\n",
+			unsupported: []string{"missing attachment zzz"},
+		},
+		{
+			name:     "lists",
+			enml:     `<en-note><ul><li>One</li><li>Two<ol><li>A</li><li>B</li></ol></li></ul></en-note>`,
+			markdown: "- One\n- Two\n  1. A\n  2. B\n",
+		},
+		{
+			name:     "table",
+			enml:     `<en-note><table><tr><th>Name</th><th>Qty</th></tr><tr><td>Milk</td><td>2 | 3</td></tr></table></en-note>`,
+			markdown: "| Name | Qty |\n| --- | --- |\n| Milk | 2 \\| 3 |\n",
+		},
+		{
+			name:     "code block",
+			enml:     `<en-note><div style="-en-codeblock: true;"><div>func main() {</div><div>    x := 1</div><div>}</div></div></en-note>`,
+			markdown: "```\nfunc main() {\n    x := 1\n}\n```\n",
+		},
+		{
+			name:     "pre",
+			enml:     "<en-note><pre>a  b\n  c</pre><p>After</p></en-note>",
+			markdown: "```\na  b\n  c\n```\n\nAfter\n",
+		},
+		{
+			name:     "inline",
+			enml:     `<en-note><h2>Title</h2><p>Text with <b>bold</b>, <i>italics</i> and <a href="https://example.org">a link</a></p></en-note>`,
+			markdown: "## Title\n\nText with **bold**, *italics* and [a link](https://example.org)\n",
+		},
+		{
+			name:        "encrypted",
+			enml:        `<en-note><div>Secret: <en-crypt cipher="AES">abc</en-crypt></div></en-note>`,
+			markdown:    "Secret:\n",
+			unsupported: []string{"encrypted text"},
+		},
+	}
+	for _, test := range tests {
+		markdown, unsupported, err := enmlToMarkdown(test.enml, media)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if markdown != test.markdown {
+			t.Errorf("%s: got %q, want %q", test.name, markdown, test.markdown)
+		}
+		if !reflect.DeepEqual(unsupported, test.unsupported) {
+			t.Errorf("%s: got unsupported %v, want %v", test.name, unsupported, test.unsupported)
+		}
+	}
+}
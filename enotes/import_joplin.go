@@ -0,0 +1,196 @@
+package enotes
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Joplin item types, as found in the type_ property.
+const (
+	joplinNote    = "1"
+	joplinFolder  = "2"
+	joplinTag     = "5"
+	joplinNoteTag = "6"
+)
+
+type joplinItem struct {
+	title string
+	body  string
+	props map[string]string
+}
+
+func (i joplinItem) time(user, fallback string) time.Time {
+	for _, key := range []string{user, fallback} {
+		if t, err := time.Parse(time.RFC3339, i.props[key]); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ImportJoplin imports the notes of a Joplin export archive (JEX). Notebooks
+// are kept, and the title, tags and timestamps of every note are saved in its
+// metadata. Resources (attachments) are not imported.
+func ImportJoplin(jexPath string, password string, policy ConflictPolicy) (*ImportResult, error) {
+	file, err := os.Open(jexPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	items := map[string]joplinItem{}
+	resources := 0
+	tr := tar.NewReader(file)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", jexPath, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if strings.HasPrefix(hdr.Name, "resources/") {
+			resources += 1
+			continue
+		}
+		if path.Ext(hdr.Name) != noteExt {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		item := parseJoplinItem(string(content))
+		if id := item.props["id"]; id != "" {
+			items[id] = item
+		}
+	}
+
+	tags := map[string][]string{}
+	for _, item := range items {
+		if item.props["type_"] != joplinNoteTag {
+			continue
+		}
+		if tag, ok := items[item.props["tag_id"]]; ok {
+			noteID := item.props["note_id"]
+			tags[noteID] = append(tags[noteID], tag.title)
+		}
+	}
+
+	var notes []string
+	for id, item := range items {
+		if item.props["type_"] != joplinNote {
+			continue
+		}
+		if item.props["deleted_time"] != "" && item.props["deleted_time"] != "0" {
+			continue
+		}
+		notes = append(notes, id)
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		return items[notes[i]].props["created_time"] < items[notes[j]].props["created_time"]
+	})
+
+	result := &ImportResult{}
+	for _, id := range notes {
+		item := items[id]
+
+		meta := Metadata{
+			Title:   item.title,
+			Tags:    tags[id],
+			Created: item.time("user_created_time", "created_time"),
+			Updated: item.time("user_updated_time", "updated_time"),
+		}
+		name := filepath.Join(joplinNotebook(items, item.props["parent_id"]), noteNameFromTitle(item.title))
+		saved, err := importNote(name, []byte(FormatNote(meta, item.body)), meta.Updated, password, policy)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", name, err)
+		}
+		if saved == "" {
+			result.Skipped = append(result.Skipped, name)
+		} else {
+			result.Imported = append(result.Imported, saved)
+		}
+	}
+
+	if resources > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%d resources (attachments) were not imported", resources))
+	}
+	return result, nil
+}
+
+// joplinNotebook returns the notebook path of the folder with the given id.
+func joplinNotebook(items map[string]joplinItem, id string) string {
+	var parts []string
+	seen := map[string]bool{}
+	for id != "" && !seen[id] {
+		seen[id] = true
+		folder, ok := items[id]
+		if !ok || folder.props["type_"] != joplinFolder {
+			break
+		}
+		parts = append([]string{noteNameFromTitle(folder.title)}, parts...)
+		id = folder.props["parent_id"]
+	}
+	return filepath.Join(parts...)
+}
+
+// parseJoplinItem parses a serialized Joplin item: the title in the first line,
+// then the body, then a block of "key: value" properties.
+func parseJoplinItem(content string) joplinItem {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	item := joplinItem{props: map[string]string{}}
+	i := len(lines) - 1
+	for ; i >= 0; i-- {
+		key, value, ok := strings.Cut(lines[i], ": ")
+		if !ok && strings.HasSuffix(lines[i], ":") {
+			key, ok = strings.TrimSuffix(lines[i], ":"), true
+		}
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			break
+		}
+		item.props[key] = value
+	}
+
+	// Items without title or body, like note tags, only have properties.
+	if i < 0 {
+		return item
+	}
+	item.title = lines[0]
+	if i > 1 {
+		item.body = strings.TrimSpace(strings.Join(lines[1:i], "\n")) + "\n"
+	}
+	return item
+}
+
+// noteNameFromTitle turns a title from another application into a note name.
+func noteNameFromTitle(title string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\':
+			return '-'
+		case r < ' ':
+			return -1
+		}
+		return r
+	}, title)
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+	if runes := []rune(name); len(runes) > 100 {
+		name = strings.TrimSpace(string(runes[:100]))
+	}
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
@@ -0,0 +1,115 @@
+package enotes
+
+import (
+	"archive/tar"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const joplinTestNote = `Groceries: weekly
+
+Milk
+Bread
+
+id: n1
+parent_id: f1
+created_time: 2022-08-01T10:00:00.000Z
+updated_time: 2022-08-02T18:30:00.000Z
+user_created_time: 2022-07-30T09:00:00.000Z
+user_updated_time: 2022-08-02T18:30:00.000Z
+deleted_time: 0
+type_: 1`
+
+func TestParseJoplinItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		title   string
+		body    string
+		props   map[string]string
+	}{
+		{
+			name:    "note",
+			content: joplinTestNote,
+			title:   "Groceries: weekly",
+			body:    "Milk\nBread\n",
+			props: map[string]string{
+				"id":                "n1",
+				"parent_id":         "f1",
+				"created_time":      "2022-08-01T10:00:00.000Z",
+				"updated_time":      "2022-08-02T18:30:00.000Z",
+				"user_created_time": "2022-07-30T09:00:00.000Z",
+				"user_updated_time": "2022-08-02T18:30:00.000Z",
+				"deleted_time":      "0",
+				"type_":             "1",
+			},
+		},
+		{
+			name:    "folder",
+			content: "Home\n\nid: f1\nparent_id:\ntype_: 2\n",
+			title:   "Home",
+			props:   map[string]string{"id": "f1", "parent_id": "", "type_": "2"},
+		},
+		{
+			name:    "note tag",
+			content: "id: nt1\nnote_id: n1\ntag_id: t1\ntype_: 6",
+			props:   map[string]string{"id": "nt1", "note_id": "n1", "tag_id": "t1", "type_": "6"},
+		},
+	}
+	for _, test := range tests {
+		item := parseJoplinItem(test.content)
+		if item.title != test.title || item.body != test.body || !reflect.DeepEqual(item.props, test.props) {
+			t.Errorf("%s: got %q, %q, %v, want %q, %q, %v",
+				test.name, item.title, item.body, item.props, test.title, test.body, test.props)
+		}
+	}
+}
+
+func TestImportJoplin(t *testing.T) {
+	useTempVault(t)
+	file, err := os.Create("export.jex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := tar.NewWriter(file)
+	entries := map[string]string{
+		"n1.md":          joplinTestNote,
+		"f1.md":          "Home\n\nid: f1\nparent_id: \ntype_: 2",
+		"t1.md":          "weekly\n\nid: t1\ntype_: 5",
+		"nt1.md":         "id: nt1\nnote_id: n1\ntag_id: t1\ntype_: 6",
+		"resources/r1.a": "resource",
+	}
+	for name, content := range entries {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	result, err := ImportJoplin("export.jex", testPassword, ConflictRename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Imported, []string{"Home/Groceries: weekly"}) || len(result.Warnings) != 1 {
+		t.Fatalf("got imported %v and warnings %v", result.Imported, result.Warnings)
+	}
+
+	content, err := OpenNote(NotePath("Home/Groceries: weekly"), testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta, body := ParseNote(content)
+	want := Metadata{
+		Title:   "Groceries: weekly",
+		Tags:    []string{"weekly"},
+		Created: time.Date(2022, 7, 30, 9, 0, 0, 0, time.UTC),
+		Updated: time.Date(2022, 8, 2, 18, 30, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(meta, want) || body != "Milk\nBread\n" {
+		t.Errorf("got %+v, %q, want %+v, %q", meta, body, want, "Milk\nBread\n")
+	}
+}
@@ -0,0 +1,105 @@
+package enotes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+type standardNotesBackup struct {
+	Items []standardNotesItem `json:"items"`
+}
+
+type standardNotesItem struct {
+	UUID        string          `json:"uuid"`
+	ContentType string          `json:"content_type"`
+	Content     json.RawMessage `json:"content"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Deleted     bool            `json:"deleted"`
+}
+
+type standardNotesContent struct {
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+	Trashed    bool   `json:"trashed"`
+	References []struct {
+		UUID        string `json:"uuid"`
+		ContentType string `json:"content_type"`
+	} `json:"references"`
+}
+
+// ImportStandardNotes imports the notes of a decrypted Standard Notes backup
+// file, saving the title, tags and timestamps of every note in its metadata.
+// Trashed notes are skipped.
+func ImportStandardNotes(backupPath string, password string, policy ConflictPolicy) (*ImportResult, error) {
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	var backup standardNotesBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", backupPath, err)
+	}
+
+	contents := make(map[string]standardNotesContent, len(backup.Items))
+	for _, item := range backup.Items {
+		if item.Deleted || len(item.Content) == 0 {
+			continue
+		}
+		var content standardNotesContent
+		if err := json.Unmarshal(item.Content, &content); err != nil {
+			var encrypted string
+			if json.Unmarshal(item.Content, &encrypted) == nil {
+				return nil, errors.New("the backup is encrypted, export a decrypted backup from Standard Notes")
+			}
+			return nil, fmt.Errorf("reading item %s: %w", item.UUID, err)
+		}
+		contents[item.UUID] = content
+	}
+
+	tags := map[string][]string{}
+	for _, item := range backup.Items {
+		content, ok := contents[item.UUID]
+		if !ok || item.ContentType != "Tag" {
+			continue
+		}
+		for _, ref := range content.References {
+			if ref.ContentType == "Note" {
+				tags[ref.UUID] = append(tags[ref.UUID], content.Title)
+			}
+		}
+	}
+
+	result := &ImportResult{}
+	for _, item := range backup.Items {
+		content, ok := contents[item.UUID]
+		if !ok || item.ContentType != "Note" || content.Trashed {
+			continue
+		}
+
+		meta := Metadata{
+			Title:   content.Title,
+			Tags:    tags[item.UUID],
+			Created: item.CreatedAt,
+			Updated: item.UpdatedAt,
+		}
+		body := content.Text
+		if body != "" && body[len(body)-1] != '\n' {
+			body += "\n"
+		}
+		name := noteNameFromTitle(content.Title)
+		saved, err := importNote(name, []byte(FormatNote(meta, body)), meta.Updated, password, policy)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", name, err)
+		}
+		if saved == "" {
+			result.Skipped = append(result.Skipped, name)
+		} else {
+			result.Imported = append(result.Imported, saved)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package enotes
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const standardNotesTestBackup = `{
+  "items": [
+    {
+      "uuid": "n1",
+      "content_type": "Note",
+      "content": {"title": "Groceries", "text": "Milk\nBread", "references": []},
+      "created_at": "2022-08-01T10:00:00.000Z",
+      "updated_at": "2022-08-02T18:30:00.000Z"
+    },
+    {
+      "uuid": "n2",
+      "content_type": "Note",
+      "content": {"title": "Old", "text": "Trashed", "trashed": true},
+      "created_at": "2022-08-01T10:00:00.000Z",
+      "updated_at": "2022-08-01T10:00:00.000Z"
+    },
+    {
+      "uuid": "t1",
+      "content_type": "Tag",
+      "content": {"title": "home", "references": [{"uuid": "n1", "content_type": "Note"}]},
+      "created_at": "2022-08-01T10:00:00.000Z",
+      "updated_at": "2022-08-01T10:00:00.000Z"
+    },
+    {
+      "uuid": "n3",
+      "content_type": "Note",
+      "deleted": true,
+      "created_at": "2022-08-01T10:00:00.000Z",
+      "updated_at": "2022-08-01T10:00:00.000Z"
+    }
+  ]
+}`
+
+func TestImportStandardNotes(t *testing.T) {
+	useTempVault(t)
+	if err := os.WriteFile("backup.txt", []byte(standardNotesTestBackup), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ImportStandardNotes("backup.txt", testPassword, ConflictRename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Imported, []string{"Groceries"}) {
+		t.Fatalf("got imported %v, want Groceries", result.Imported)
+	}
+
+	content, err := OpenNote(NotePath("Groceries"), testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta, body := ParseNote(content)
+	want := Metadata{
+		Title:   "Groceries",
+		Tags:    []string{"home"},
+		Created: time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC),
+		Updated: time.Date(2022, 8, 2, 18, 30, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(meta, want) || body != "Milk\nBread\n" {
+		t.Errorf("got %+v, %q, want %+v, %q", meta, body, want, "Milk\nBread\n")
+	}
+}
+
+func TestImportStandardNotesEncrypted(t *testing.T) {
+	useTempVault(t)
+	backup := `{"items": [{"uuid": "n1", "content_type": "Note", "content": "004:abc"}]}`
+	if err := os.WriteFile("backup.txt", []byte(backup), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ImportStandardNotes("backup.txt", testPassword, ConflictRename)
+	if err == nil || !strings.Contains(err.Error(), "encrypted") {
+		t.Errorf("got error %v, want an encrypted backup", err)
+	}
+}
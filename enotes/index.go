@@ -0,0 +1,122 @@
+package enotes
+
+import (
+	"os"
+	"time"
+)
+
+// The link and search indexes keep the modification time of every note they
+// read, so they are brought up to date by reading again only the notes that
+// changed since, including the ones changed outside enotes. Both are refreshed
+// together, so every changed note is decrypted once. enotes never deletes
+// notes: notes removed outside it are noticed because they are missing from
+// the vault when the indexes are refreshed, and are dropped from them then.
+// Notes that can't be decrypted are left out of the indexes instead of failing
+// every search, Check reports them.
+
+// noteIndex is an index of the contents of the notes.
+type noteIndex interface {
+	// modTime returns the modification time of the note called name when
+	// it was indexed, and false if it isn't in the index.
+	modTime(name string) (time.Time, bool)
+	set(name string, modTime time.Time, content string)
+	remove(name string)
+	names() []string
+}
+
+// undecryptableNotes has the modification time of the notes that couldn't be
+// decrypted by path, so they aren't decrypted again until they change. Like
+// the indexes, it is kept for a single password.
+var undecryptableNotes struct {
+	modTimes map[string]time.Time
+	password string
+}
+
+// lockIndexes locks the caches of both indexes, always in the same order, and
+// returns a function that unlocks them.
+func lockIndexes() func() {
+	linkIndexCache.Lock()
+	searchIndexCache.Lock()
+	return func() {
+		searchIndexCache.Unlock()
+		linkIndexCache.Unlock()
+	}
+}
+
+// refreshIndexes indexes the notes that changed since they were last indexed,
+// forgets the ones that no longer exist and saves the indexes that changed. It
+// returns the set of existing notes. Both indexes must be locked.
+func refreshIndexes(password string) (map[string]bool, error) {
+	indexes := []struct {
+		index    noteIndex
+		fileName string
+		changed  bool
+	}{
+		{index: loadLinkIndex(password), fileName: linkIndexFileName},
+		{index: loadSearchIndex(password), fileName: searchIndexFileName},
+	}
+	if undecryptableNotes.modTimes == nil || undecryptableNotes.password != password {
+		undecryptableNotes.modTimes = map[string]time.Time{}
+		undecryptableNotes.password = password
+	}
+	failed := undecryptableNotes.modTimes
+
+	paths, err := ListNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	notes := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		name := NoteName(p)
+		notes[name] = true
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+
+		var stale []int
+		for i, idx := range indexes {
+			if modTime, ok := idx.index.modTime(name); !ok || !modTime.Equal(info.ModTime()) {
+				stale = append(stale, i)
+			}
+		}
+		if len(stale) == 0 {
+			continue
+		}
+		if modTime, ok := failed[p]; ok && modTime.Equal(info.ModTime()) {
+			continue
+		}
+		content, err := decrypt(p, password)
+		if err != nil {
+			failed[p] = info.ModTime()
+			continue
+		}
+		delete(failed, p)
+		for _, i := range stale {
+			indexes[i].index.set(name, info.ModTime(), content.String())
+			indexes[i].changed = true
+		}
+	}
+	for p := range failed {
+		if !notes[NoteName(p)] {
+			delete(failed, p)
+		}
+	}
+
+	for i := range indexes {
+		idx := &indexes[i]
+		for _, name := range idx.index.names() {
+			if !notes[name] {
+				idx.index.remove(name)
+				idx.changed = true
+			}
+		}
+		if idx.changed {
+			if err := writeEncryptedJSON(idx.fileName, idx.index, password); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return notes, nil
+}
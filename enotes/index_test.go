@@ -0,0 +1,51 @@
+package enotes
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestRefreshIndexes(t *testing.T) {
+	useTempVault(t)
+	for name, content := range map[string]string{
+		"todo":  "Buy milk, see [[ideas]]",
+		"ideas": "Plant a garden",
+	} {
+		if err := saveNote(NotePath(name), []byte(content), testPassword); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(NotePath("broken"), []byte("not age"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	backlinks, err := Backlinks("ideas", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(backlinks, []string{"todo"}) {
+		t.Errorf("got backlinks %v, want todo", backlinks)
+	}
+	// The search index was refreshed along with the link index.
+	if _, ok := searchIndexCache.index.Notes["ideas"]; !ok {
+		t.Error("the search index wasn't refreshed with the link index")
+	}
+	if _, ok := undecryptableNotes.modTimes[NotePath("broken")]; !ok {
+		t.Error("the note that can't be decrypted wasn't recorded")
+	}
+
+	if err := os.Remove(NotePath("todo")); err != nil {
+		t.Fatal(err)
+	}
+	results, err := Search("milk", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("got results %v for a removed note", results)
+	}
+	if _, ok := linkIndexCache.index.Notes["todo"]; ok {
+		t.Error("the removed note is still in the link index")
+	}
+}
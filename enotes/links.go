@@ -0,0 +1,161 @@
+package enotes
+
+import (
+	"bytes"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Notes link to each other with wiki links like [[Groceries]], or
+// [[Groceries|the list]] to show a different text. The target is the name of
+// a note, looked up first in the notebook of the linking note and then from
+// the root of the vault.
+
+// WikiLink is a wiki link found in a note body. Start and End are the byte
+// offsets of the whole link, brackets included.
+type WikiLink struct {
+	Target string
+	Label  string
+	Start  int
+	End    int
+	// Name is the name of the note the link resolves to, and Exists reports
+	// whether that note exists. Name is empty if the target is not a valid
+	// note name.
+	Name   string
+	Exists bool
+}
+
+var kindWikiLink = ast.NewNodeKind("WikiLink")
+
+type wikiLinkNode struct {
+	ast.BaseInline
+	target string
+	label  string
+	start  int
+	end    int
+}
+
+func (n *wikiLinkNode) Kind() ast.NodeKind {
+	return kindWikiLink
+}
+
+func (n *wikiLinkNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Target": n.target, "Label": n.label}, nil)
+}
+
+type wikiLinkParser struct{}
+
+func (wikiLinkParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (wikiLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+	end := bytes.Index(line, []byte("]]"))
+	if end == -1 {
+		return nil
+	}
+	inner := string(line[2:end])
+	if strings.ContainsAny(inner, "[]\n") {
+		return nil
+	}
+	target, label, _ := strings.Cut(inner, "|")
+	target = strings.TrimSpace(target)
+	label = strings.TrimSpace(label)
+	if target == "" {
+		return nil
+	}
+	if label == "" {
+		label = target
+	}
+	block.Advance(end + 2)
+	return &wikiLinkNode{
+		target: target,
+		label:  label,
+		start:  segment.Start,
+		end:    segment.Start + end + 2,
+	}
+}
+
+var wikiLinkMarkdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(
+		parser.WithInlineParsers(util.Prioritized(wikiLinkParser{}, 199)),
+	),
+)
+
+// WikiLinks returns the wiki links in body, the contents of the note called
+// name without its front matter, resolving them to the notes they point to.
+// Links inside code are ignored.
+func WikiLinks(name string, body string) []WikiLink {
+	doc := wikiLinkMarkdown.Parser().Parse(text.NewReader([]byte(body)))
+	var links []WikiLink
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if n, ok := n.(*wikiLinkNode); ok && entering {
+			link := WikiLink{Target: n.target, Label: n.label, Start: n.start, End: n.end}
+			link.Name, link.Exists = ResolveWikiLink(name, n.target)
+			links = append(links, link)
+		}
+		return ast.WalkContinue, nil
+	})
+	return links
+}
+
+// ResolveWikiLink returns the name of the note a wiki link to target from the
+// note called from points to, and whether it exists. Targets that don't exist
+// resolve to a note at the root of the vault, so following them creates it
+// there. The name is empty if target points outside the vault.
+func ResolveWikiLink(from string, target string) (string, bool) {
+	return resolveWikiLink(from, target, func(name string) bool {
+		exists, _ := NoteExists(name)
+		return exists
+	})
+}
+
+// resolveWikiLink is ResolveWikiLink with exists reporting whether a note
+// exists.
+func resolveWikiLink(from string, target string, exists func(name string) bool) (string, bool) {
+	target, _, _ = strings.Cut(target, "#")
+	target = strings.TrimSuffix(strings.TrimSuffix(target, ".age"), noteExt)
+	target = path.Clean(strings.TrimPrefix(filepath.ToSlash(target), "/"))
+	if target == "." || target == ".." || strings.HasPrefix(target, "../") {
+		return "", false
+	}
+
+	var candidates []string
+	if dir := path.Dir(filepath.ToSlash(from)); dir != "." {
+		candidates = append(candidates, path.Join(dir, target))
+	}
+	candidates = append(candidates, target)
+	for _, candidate := range candidates {
+		name := filepath.FromSlash(candidate)
+		if exists(name) {
+			return name, true
+		}
+	}
+	return filepath.FromSlash(target), false
+}
+
+// wikiLinkTargets returns the target of every wiki link in body.
+func wikiLinkTargets(body string) []string {
+	doc := wikiLinkMarkdown.Parser().Parse(text.NewReader([]byte(body)))
+	var targets []string
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if n, ok := n.(*wikiLinkNode); ok && entering {
+			targets = append(targets, n.target)
+		}
+		return ast.WalkContinue, nil
+	})
+	return targets
+}
@@ -0,0 +1,73 @@
+package enotes
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveWikiLink(t *testing.T) {
+	notes := map[string]bool{
+		"ideas":       true,
+		"work/ideas":  true,
+		"work/plans":  true,
+		"home/garden": true,
+	}
+	exists := func(name string) bool {
+		return notes[filepath.ToSlash(name)]
+	}
+	tests := []struct {
+		from   string
+		target string
+		name   string
+		exists bool
+	}{
+		{"todo", "ideas", "ideas", true},
+		{"work/todo", "ideas", "work/ideas", true},
+		{"work/todo", "plans", "work/plans", true},
+		{"home/todo", "ideas", "ideas", true},
+		{"todo", "work/plans", "work/plans", true},
+		{"home/todo", "work/plans", "work/plans", true},
+		{"home/todo", "/ideas", "ideas", true},
+		{"todo", "ideas.md", "ideas", true},
+		{"todo", "ideas.md.age", "ideas", true},
+		{"todo", "ideas#Heading", "ideas", true},
+		{"todo", "missing", "missing", false},
+		{"work/todo", "missing", "missing", false},
+		{"todo", "../outside", "", false},
+		{"work/todo", "../../outside", "", false},
+		{"work/todo", "../home/garden", "", false},
+		{"todo", "..", "", false},
+	}
+	for _, test := range tests {
+		name, ok := resolveWikiLink(test.from, test.target, exists)
+		if name != filepath.FromSlash(test.name) || ok != test.exists {
+			t.Errorf("resolveWikiLink(%q, %q) = %q, %v, want %q, %v",
+				test.from, test.target, name, ok, test.name, test.exists)
+		}
+	}
+}
+
+func TestWikiLinks(t *testing.T) {
+	useTempVault(t)
+	for _, name := range []string{"ideas", "work/plans"} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(NotePath(name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	body := "See [[plans]], [[ideas|my ideas]] and [[missing]].\n\n`[[in code]]`\n"
+	links := WikiLinks("work/todo", body)
+	want := []WikiLink{
+		{Target: "plans", Label: "plans", Start: 4, End: 13, Name: filepath.FromSlash("work/plans"), Exists: true},
+		{Target: "ideas", Label: "my ideas", Start: 15, End: 33, Name: "ideas", Exists: true},
+		{Target: "missing", Label: "missing", Start: 38, End: 49, Name: "missing"},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("got %+v, want %+v", links, want)
+	}
+}
@@ -0,0 +1,160 @@
+package enotes
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const frontMatterDelimiter = "---"
+
+var metadataKeys = map[string]bool{"title": true, "tags": true, "created": true, "updated": true}
+
+// Metadata is kept in a front matter block at the start of the note contents,
+// so it is encrypted along with the note:
+//
+//	---
+//	title: Groceries
+//	tags: [home, weekly]
+//	created: 2022-08-01T10:00:00Z
+//	updated: 2022-08-02T18:30:00Z
+//	---
+type Metadata struct {
+	Title   string
+	Tags    []string
+	Created time.Time
+	Updated time.Time
+}
+
+func (m Metadata) IsZero() bool {
+	return m.Title == "" && len(m.Tags) == 0 && m.Created.IsZero() && m.Updated.IsZero()
+}
+
+// FormatNote returns body preceded by a front matter block with meta, or body
+// alone if meta is empty.
+func FormatNote(meta Metadata, body string) string {
+	if meta.IsZero() {
+		return body
+	}
+
+	var b strings.Builder
+	b.WriteString(frontMatterDelimiter + "\n")
+	if meta.Title != "" {
+		b.WriteString("title: " + quoteMetadata(meta.Title) + "\n")
+	}
+	if len(meta.Tags) > 0 {
+		tags := make([]string, len(meta.Tags))
+		for i, tag := range meta.Tags {
+			tags[i] = quoteMetadata(tag)
+		}
+		b.WriteString("tags: [" + strings.Join(tags, ", ") + "]\n")
+	}
+	if !meta.Created.IsZero() {
+		b.WriteString("created: " + meta.Created.UTC().Format(time.RFC3339) + "\n")
+	}
+	if !meta.Updated.IsZero() {
+		b.WriteString("updated: " + meta.Updated.UTC().Format(time.RFC3339) + "\n")
+	}
+	b.WriteString(frontMatterDelimiter + "\n\n")
+	b.WriteString(body)
+	return b.String()
+}
+
+// ParseNote splits the note contents into its metadata and its body. Notes
+// without a front matter block have empty metadata. A block is only front
+// matter if every line in it has one of the keys of Metadata, so a note
+// starting with a thematic break keeps the text up to the next one.
+func ParseNote(content string) (Metadata, string) {
+	var meta Metadata
+	if !strings.HasPrefix(content, frontMatterDelimiter+"\n") {
+		return meta, content
+	}
+	rest := content[len(frontMatterDelimiter)+1:]
+	end := strings.Index(rest, "\n"+frontMatterDelimiter+"\n")
+	if end == -1 {
+		if !strings.HasSuffix(rest, "\n"+frontMatterDelimiter) {
+			return meta, content
+		}
+		end = len(rest) - len(frontMatterDelimiter) - 1
+	}
+	header := rest[:end]
+	body := strings.TrimPrefix(rest[end+1:], frontMatterDelimiter)
+	body = strings.TrimPrefix(strings.TrimPrefix(body, "\n"), "\n")
+
+	lines := strings.Split(header, "\n")
+	for _, line := range lines {
+		key, _, ok := strings.Cut(line, ":")
+		if strings.TrimSpace(line) != "" && (!ok || !metadataKeys[strings.TrimSpace(key)]) {
+			return meta, content
+		}
+	}
+	for _, line := range lines {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "title":
+			meta.Title = unquoteMetadata(value)
+		case "tags":
+			meta.Tags = parseMetadataList(value)
+		case "created":
+			meta.Created, _ = time.Parse(time.RFC3339, value)
+		case "updated":
+			meta.Updated, _ = time.Parse(time.RFC3339, value)
+		}
+	}
+	return meta, body
+}
+
+func quoteMetadata(s string) string {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s, ":#,[]{}\"'\\\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func unquoteMetadata(s string) string {
+	if strings.HasPrefix(s, "\"") {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+	}
+	if len(s) >= 2 && strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
+
+func parseMetadataList(s string) []string {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	var items []string
+	for len(s) > 0 {
+		s = strings.TrimSpace(s)
+		var item string
+		if strings.HasPrefix(s, "\"") {
+			// Find the closing quote, skipping escaped ones.
+			end := 1
+			for end < len(s) && (s[end] != '"' || s[end-1] == '\\') {
+				end++
+			}
+			if end < len(s) {
+				end++
+			}
+			item, s = s[:end], s[end:]
+			s = strings.TrimPrefix(strings.TrimSpace(s), ",")
+		} else {
+			var ok bool
+			item, s, ok = strings.Cut(s, ",")
+			if !ok {
+				s = ""
+			}
+			item = strings.TrimSpace(item)
+		}
+		if item = unquoteMetadata(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,80 @@
+package enotes
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseNote(t *testing.T) {
+	created := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		content string
+		meta    Metadata
+		body    string
+	}{
+		{
+			name:    "no front matter",
+			content: "# Groceries\n\nMilk\n",
+			body:    "# Groceries\n\nMilk\n",
+		},
+		{
+			name:    "known keys",
+			content: "---\ntitle: Groceries\ntags: [home, \"weekly, big\"]\ncreated: 2022-08-01T10:00:00Z\n---\n\nMilk\n",
+			meta:    Metadata{Title: "Groceries", Tags: []string{"home", "weekly, big"}, Created: created},
+			body:    "Milk\n",
+		},
+		{
+			name:    "quoted title",
+			content: "---\ntitle: \"Meeting: 2024\"\n---\nNotes\n",
+			meta:    Metadata{Title: "Meeting: 2024"},
+			body:    "Notes\n",
+		},
+		{
+			name:    "only front matter",
+			content: "---\ntitle: Empty\n---",
+			meta:    Metadata{Title: "Empty"},
+		},
+		{
+			name:    "unknown key",
+			content: "---\ntitle: Groceries\nauthor: me\n---\n\nMilk\n",
+			body:    "---\ntitle: Groceries\nauthor: me\n---\n\nMilk\n",
+		},
+		{
+			name:    "thematic break",
+			content: "---\nSome text\n---\n\nMore text\n",
+			body:    "---\nSome text\n---\n\nMore text\n",
+		},
+		{
+			name:    "unclosed",
+			content: "---\ntitle: Groceries\n\nMilk\n",
+			body:    "---\ntitle: Groceries\n\nMilk\n",
+		},
+	}
+	for _, test := range tests {
+		meta, body := ParseNote(test.content)
+		if !reflect.DeepEqual(meta, test.meta) {
+			t.Errorf("%s: got metadata %+v, want %+v", test.name, meta, test.meta)
+		}
+		if body != test.body {
+			t.Errorf("%s: got body %q, want %q", test.name, body, test.body)
+		}
+	}
+}
+
+func TestFormatNote(t *testing.T) {
+	meta := Metadata{
+		Title:   "Meeting: 2024",
+		Tags:    []string{"work", "a, b"},
+		Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	content := FormatNote(meta, "Notes\n")
+	parsed, body := ParseNote(content)
+	if !reflect.DeepEqual(parsed, meta) || body != "Notes\n" {
+		t.Errorf("ParseNote(FormatNote(...)) = %+v, %q, want %+v, %q", parsed, body, meta, "Notes\n")
+	}
+	if content := FormatNote(Metadata{}, "Notes\n"); content != "Notes\n" {
+		t.Errorf("got %q without metadata, want the body alone", content)
+	}
+}
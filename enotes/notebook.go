@@ -0,0 +1,45 @@
+package enotes
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Notes can be organized in notebooks, which are just subdirectories of the
+// vault. Hidden directories and the ones holding attachments are never
+// considered notebooks.
+
+// ListNotes returns the path of every note in the vault, including the ones in
+// notebooks, sorted alphabetically.
+func ListNotes() ([]string, error) {
+	var notes []string
+	err := walkVault(func(path string) error {
+		if IsNote(path) && !isAttachment(path) {
+			notes = append(notes, path)
+		}
+		return nil
+	})
+	sort.Strings(notes)
+	return notes, err
+}
+
+// walkVault calls fn with the path of every regular file in the vault.
+func walkVault(fn func(path string) error) error {
+	return filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != "." && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return fn(path)
+	})
+}
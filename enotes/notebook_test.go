@@ -0,0 +1,43 @@
+package enotes
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListNotes(t *testing.T) {
+	useTempVault(t)
+	files := []string{
+		"todo.md.age",
+		"todo.files/scan.pdf.age",
+		"todo.files/draft.md.age",
+		"work/plans.md.age",
+		"work/plans.files/old.md.age",
+		"work.files/meeting.md.age",
+		".hidden/secret.md.age",
+		".enotes-password.age",
+		"readme.txt",
+	}
+	for _, file := range files {
+		if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notes, err := ListNotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"todo.md.age", "work.files/meeting.md.age", "work/plans.md.age"}
+	for i := range want {
+		want[i] = filepath.FromSlash(want[i])
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("got %v, want %v", notes, want)
+	}
+}
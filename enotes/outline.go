@@ -0,0 +1,76 @@
+package enotes
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Heading is a Markdown heading of a note body. Start and End are the byte
+// offsets of the heading itself, and SectionEnd the offset where its section
+// ends: the next heading of the same or a higher level, or the end of the body.
+type Heading struct {
+	Level      int
+	Text       string
+	Start      int
+	End        int
+	SectionEnd int
+}
+
+// Headings returns the headings of body, the contents of a note without its
+// front matter, leaving out the ones nested in lists or quotes.
+func Headings(body string) []Heading {
+	source := []byte(body)
+	doc := markdown.Parser().Parse(text.NewReader(source))
+
+	var headings []Heading
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		h, ok := n.(*ast.Heading)
+		if !ok || h.Lines().Len() == 0 {
+			continue
+		}
+		first := h.Lines().At(0)
+		last := h.Lines().At(h.Lines().Len() - 1)
+		start := lineStart(body, first.Start)
+		end := lineEnd(body, last.Stop)
+		if !strings.HasPrefix(strings.TrimLeft(body[start:end], " "), "#") {
+			// Setext headings are underlined in the next line.
+			end = lineEnd(body, end+1)
+		}
+		headings = append(headings, Heading{
+			Level: h.Level,
+			Text:  string(h.Text(source)),
+			Start: start,
+			End:   end,
+		})
+	}
+
+	for i := range headings {
+		headings[i].SectionEnd = len(body)
+		for _, next := range headings[i+1:] {
+			if next.Level <= headings[i].Level {
+				headings[i].SectionEnd = next.Start
+				break
+			}
+		}
+	}
+	return headings
+}
+
+func lineStart(s string, i int) int {
+	return strings.LastIndex(s[:i], "\n") + 1
+}
+
+// lineEnd returns the offset of the end of the line containing i, including
+// its newline.
+func lineEnd(s string, i int) int {
+	if i >= len(s) {
+		return len(s)
+	}
+	j := strings.Index(s[i:], "\n")
+	if j == -1 {
+		return len(s)
+	}
+	return i + j + 1
+}
@@ -0,0 +1,59 @@
+package enotes
+
+import (
+	"sort"
+	"sync"
+)
+
+// Pinned notes are listed before the rest. Their names are kept in an
+// encrypted file in the vault, so which notes matter most isn't written to
+// disk in plaintext.
+
+const pinsFileName = ".enotes-pins.age"
+
+type pinnedNotes struct {
+	Notes []string `json:"notes"`
+}
+
+var pinsMu sync.Mutex
+
+// PinnedNotes returns the names of the pinned notes, sorted alphabetically.
+func PinnedNotes(password string) ([]string, error) {
+	pinsMu.Lock()
+	defer pinsMu.Unlock()
+
+	var pins pinnedNotes
+	if err := readEncryptedJSON(pinsFileName, &pins, password); err != nil {
+		return nil, err
+	}
+	return pins.Notes, nil
+}
+
+// SetPinned pins or unpins the note called name, returning the pinned notes.
+func SetPinned(name string, pinned bool, password string) ([]string, error) {
+	pinsMu.Lock()
+	defer pinsMu.Unlock()
+
+	var pins pinnedNotes
+	if err := readEncryptedJSON(pinsFileName, &pins, password); err != nil {
+		return nil, err
+	}
+	notes := pins.Notes[:0]
+	for _, note := range pins.Notes {
+		if note != name {
+			notes = append(notes, note)
+		}
+	}
+	if pinned {
+		notes = append(notes, name)
+		sort.Strings(notes)
+	}
+	if len(notes) == len(pins.Notes) && !pinned {
+		return notes, nil
+	}
+	pins.Notes = notes
+	if err := writeEncryptedJSON(pinsFileName, pins, password); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
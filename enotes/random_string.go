@@ -24,13 +24,33 @@ func assertAvailablePRNG() {
 	}
 }
 
+// DefaultPasswordAlphabet is the alphabet of the passwords GeneratePassword
+// generates when none is given.
+const DefaultPasswordAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz!#$%&*+-.:;=?@^_~"
+
 // GenerateRandomString returns a securely generated random string.
 // It will return an error if the system's secure random
 // number generator fails to function correctly, in which
 // case the caller should not continue.
 func GenerateRandomString(n int) (string, error) {
-	const letters = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz-"
-	ret := make([]byte, n)
+	return generateRandomString(n, "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz-")
+}
+
+// GeneratePassword returns a securely generated password of length characters
+// picked from alphabet, or from DefaultPasswordAlphabet if it is empty.
+func GeneratePassword(length int, alphabet string) (string, error) {
+	if length <= 0 {
+		return "", fmt.Errorf("invalid password length %d", length)
+	}
+	if alphabet == "" {
+		alphabet = DefaultPasswordAlphabet
+	}
+	return generateRandomString(length, alphabet)
+}
+
+func generateRandomString(n int, alphabet string) (string, error) {
+	letters := []rune(alphabet)
+	ret := make([]rune, n)
 	for i := 0; i < n; i++ {
 		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
 		if err != nil {
@@ -0,0 +1,277 @@
+package enotes
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Searching uses an inverted index of the words in every note, encrypted like
+// the notes so no word is ever written to disk in plaintext. It is kept up to
+// date when notes are saved from enotes, and refreshed together with the link
+// index the next time either is used.
+
+const (
+	searchIndexFileName = ".enotes-index.age"
+	// searchIndexVersion changes when the index keeps something new about
+	// the notes, so older indexes are built again.
+	searchIndexVersion = 1
+	// Words in the title, tags and name of a note count this many times.
+	searchTitleWeight = 3
+)
+
+type searchIndex struct {
+	Version int                        `json:"version"`
+	Notes   map[string]searchIndexNote `json:"notes"`
+	// Postings maps every word to the number of times it appears in each note.
+	Postings map[string]map[string]int `json:"postings"`
+}
+
+type searchIndexNote struct {
+	ModTime time.Time `json:"modTime"`
+	Title   string    `json:"title,omitempty"`
+	// Created is the creation time in the front matter of the note, or the
+	// modification time of the note when it was first indexed.
+	Created time.Time `json:"created"`
+	// Length is the number of words in the note.
+	Length int `json:"length"`
+}
+
+type SearchResult struct {
+	Name  string  `json:"name"`
+	Title string  `json:"title,omitempty"`
+	Score float64 `json:"score"`
+}
+
+var searchIndexCache struct {
+	sync.Mutex
+	index    *searchIndex
+	password string
+}
+
+// LoadSearchIndex reads the search index into memory, indexing the notes that
+// changed since it was last saved, so the following searches are fast.
+func LoadSearchIndex(password string) error {
+	defer lockIndexes()()
+
+	_, err := refreshIndexes(password)
+	return err
+}
+
+// Search returns the notes containing every word in query, best matches first.
+// The last word also matches the words it is a prefix of, so results can be
+// shown while the query is typed. Notes are ranked with BM25, counting words
+// in their title, tags and name more than those in the body.
+func Search(query string, password string) ([]SearchResult, error) {
+	defer lockIndexes()()
+
+	if _, err := refreshIndexes(password); err != nil {
+		return nil, err
+	}
+	index := loadSearchIndex(password)
+
+	terms := searchTerms(query)
+	if len(terms) == 0 || len(index.Notes) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	totalLength := 0
+	for _, note := range index.Notes {
+		totalLength += note.Length
+	}
+	avgLength := float64(totalLength) / float64(len(index.Notes))
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	const k1, b = 1.2, 0.75
+	var scores map[string]float64
+	for i, term := range terms {
+		words := []string{term}
+		if i == len(terms)-1 {
+			words = index.prefixed(term)
+		}
+
+		termScores := map[string]float64{}
+		for _, word := range words {
+			postings := index.Postings[word]
+			n := float64(len(postings))
+			idf := math.Log(1 + (float64(len(index.Notes))-n+0.5)/(n+0.5))
+			for name, freq := range postings {
+				tf := float64(freq)
+				length := float64(index.Notes[name].Length)
+				termScores[name] += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*length/avgLength))
+			}
+		}
+
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for name := range scores {
+			if score, ok := termScores[name]; ok {
+				scores[name] += score
+			} else {
+				delete(scores, name)
+			}
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for name, score := range scores {
+		results = append(results, SearchResult{
+			Name:  name,
+			Title: index.Notes[name].Title,
+			Score: score,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+// CreatedTimes returns when every note was created, by name. Notes without a
+// creation time in their front matter were created when enotes first saw
+// them.
+func CreatedTimes(password string) (map[string]time.Time, error) {
+	defer lockIndexes()()
+
+	if _, err := refreshIndexes(password); err != nil {
+		return nil, err
+	}
+	index := loadSearchIndex(password)
+	created := make(map[string]time.Time, len(index.Notes))
+	for name, note := range index.Notes {
+		created[name] = note.Created
+	}
+	return created, nil
+}
+
+// indexNoteWords updates the words of the note at path, whose contents were
+// just saved, in the search index.
+func indexNoteWords(path string, content []byte, password string) error {
+	searchIndexCache.Lock()
+	defer searchIndexCache.Unlock()
+
+	index := loadSearchIndex(password)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	index.set(NoteName(path), info.ModTime(), string(content))
+	return writeEncryptedJSON(searchIndexFileName, index, password)
+}
+
+// loadSearchIndex returns the cached search index, reading it from the vault
+// the first time. searchIndexCache must be locked.
+func loadSearchIndex(password string) *searchIndex {
+	if searchIndexCache.index != nil && searchIndexCache.password == password {
+		return searchIndexCache.index
+	}
+	index := &searchIndex{}
+	if err := readEncryptedJSON(searchIndexFileName, index, password); err != nil || index.Version != searchIndexVersion {
+		// A damaged or old index is built again from the notes.
+		index = &searchIndex{Version: searchIndexVersion}
+	}
+	if index.Notes == nil {
+		index.Notes = map[string]searchIndexNote{}
+	}
+	if index.Postings == nil {
+		index.Postings = map[string]map[string]int{}
+	}
+	searchIndexCache.index = index
+	searchIndexCache.password = password
+	return index
+}
+
+func (index *searchIndex) modTime(name string) (time.Time, bool) {
+	note, ok := index.Notes[name]
+	return note.ModTime, ok
+}
+
+func (index *searchIndex) set(name string, modTime time.Time, content string) {
+	meta, body := ParseNote(content)
+	created := meta.Created
+	if created.IsZero() {
+		created = modTime
+		if note, ok := index.Notes[name]; ok && !note.Created.IsZero() {
+			created = note.Created
+		}
+	}
+	index.remove(name)
+
+	counts := map[string]int{}
+	length := 0
+	for _, word := range searchTerms(body) {
+		counts[word]++
+		length++
+	}
+	heading := meta.Title + " " + strings.Join(meta.Tags, " ") + " " + filepath.ToSlash(name)
+	for _, word := range searchTerms(heading) {
+		counts[word] += searchTitleWeight
+		length += searchTitleWeight
+	}
+
+	for word, count := range counts {
+		postings, ok := index.Postings[word]
+		if !ok {
+			postings = map[string]int{}
+			index.Postings[word] = postings
+		}
+		postings[name] = count
+	}
+	index.Notes[name] = searchIndexNote{
+		ModTime: modTime,
+		Title:   meta.Title,
+		Created: created,
+		Length:  length,
+	}
+}
+
+func (index *searchIndex) remove(name string) {
+	if _, ok := index.Notes[name]; !ok {
+		return
+	}
+	delete(index.Notes, name)
+	for word, postings := range index.Postings {
+		delete(postings, name)
+		if len(postings) == 0 {
+			delete(index.Postings, word)
+		}
+	}
+}
+
+func (index *searchIndex) names() []string {
+	names := make([]string, 0, len(index.Notes))
+	for name := range index.Notes {
+		names = append(names, name)
+	}
+	return names
+}
+
+// prefixed returns the indexed words starting with prefix.
+func (index *searchIndex) prefixed(prefix string) []string {
+	var words []string
+	for word := range index.Postings {
+		if strings.HasPrefix(word, prefix) {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// searchTerms splits s into lowercase words.
+func searchTerms(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,85 @@
+package enotes
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Notes keep passwords and other secrets in fenced code blocks with the
+// language "secret", one "key: value" field per line:
+//
+//	```secret
+//	username: alice
+//	password: correct horse battery staple
+//	url: https://example.com
+//	```
+//
+// enotes shows their values masked until they are revealed.
+
+const secretLanguage = "secret"
+
+// SecretBlock is a block of secret fields in a note body. Start and End are
+// the byte offsets of the whole block, fences included.
+type SecretBlock struct {
+	Start  int
+	End    int
+	Fields []SecretField
+}
+
+type SecretField struct {
+	Key   string
+	Value string
+}
+
+// SecretBlocks returns the blocks of secret fields in body, the contents of a
+// note without its front matter.
+func SecretBlocks(body string) []SecretBlock {
+	source := []byte(body)
+	doc := markdown.Parser().Parse(text.NewReader(source))
+
+	var blocks []SecretBlock
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		block, ok := n.(*ast.FencedCodeBlock)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		if string(block.Language(source)) != secretLanguage || block.Lines().Len() == 0 {
+			return ast.WalkSkipChildren, nil
+		}
+		lines := block.Lines()
+		first := lines.At(0)
+		last := lines.At(lines.Len() - 1)
+
+		// The fences are the lines around the contents.
+		start := lineStart(body, first.Start)
+		if start > 0 {
+			start = lineStart(body, start-1)
+		}
+		end := lineEnd(body, last.Start)
+		if fence := strings.TrimSpace(body[end:lineEnd(body, end)]); strings.HasPrefix(fence, "```") || strings.HasPrefix(fence, "~~~") {
+			end = lineEnd(body, end)
+		}
+
+		var fields []SecretField
+		for i := 0; i < lines.Len(); i++ {
+			segment := lines.At(i)
+			if field, ok := parseSecretField(string(segment.Value(source))); ok {
+				fields = append(fields, field)
+			}
+		}
+		blocks = append(blocks, SecretBlock{Start: start, End: end, Fields: fields})
+		return ast.WalkSkipChildren, nil
+	})
+	return blocks
+}
+
+func parseSecretField(line string) (SecretField, bool) {
+	key, value, ok := strings.Cut(line, ":")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return SecretField{}, false
+	}
+	return SecretField{Key: key, Value: strings.TrimSpace(value)}, true
+}
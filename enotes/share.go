@@ -0,0 +1,96 @@
+package enotes
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// ShareNote encrypts the note at path again, this time to recipient, and
+// writes it to w as ASCII armored age. recipient is either an age public key
+// (age1...) or a one-off passphrase, so the note can be handed to someone
+// without sharing the vault password.
+func ShareNote(path string, password string, recipient string, w io.Writer) error {
+	r, err := parseRecipient(recipient)
+	if err != nil {
+		return err
+	}
+
+	note, err := decrypt(path, password)
+	if err != nil {
+		return err
+	}
+
+	aw := armor.NewWriter(w)
+	ew, err := age.Encrypt(aw, r)
+	if err != nil {
+		return err
+	}
+	if _, err := ew.Write(note.Bytes()); err != nil {
+		return err
+	}
+	if err := ew.Close(); err != nil {
+		return err
+	}
+	return aw.Close()
+}
+
+// ImportShared decrypts ASCII armored age text with identity, an age secret
+// key (AGE-SECRET-KEY-1...) or a passphrase, and saves it as a new note called
+// name.
+func ImportShared(armored string, identity string, name string, password string) error {
+	if !validNoteName(name) {
+		return fmt.Errorf("invalid note name %q", name)
+	}
+	id, err := parseIdentity(identity)
+	if err != nil {
+		return err
+	}
+
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(strings.TrimSpace(armored)+"\n")), id)
+	if err != nil {
+		return err
+	}
+	content := &bytes.Buffer{}
+	if _, err := io.Copy(content, r); err != nil {
+		return err
+	}
+
+	_, err = importNote(name, content.Bytes(), time.Time{}, password, ConflictFail)
+	return err
+}
+
+// validNoteName reports whether name, typed by the user, is the name of a note
+// inside the vault that isn't hidden.
+func validNoteName(name string) bool {
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(name), "/") {
+		if part == "" || strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	return true
+}
+
+func parseRecipient(s string) (age.Recipient, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "age1") {
+		return age.ParseX25519Recipient(s)
+	}
+	return age.NewScryptRecipient(s)
+}
+
+func parseIdentity(s string) (age.Identity, error) {
+	if t := strings.TrimSpace(s); strings.HasPrefix(t, "AGE-SECRET-KEY-1") {
+		return age.ParseX25519Identity(t)
+	}
+	return age.NewScryptIdentity(s)
+}
@@ -0,0 +1,70 @@
+package enotes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidNoteName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"todo", true},
+		{"work/todo", true},
+		{"work/meeting notes", true},
+		{"todo..old", true},
+		{"", false},
+		{"/tmp/todo", false},
+		{"../todo", false},
+		{"../../x", false},
+		{"work/../../x", false},
+		{"work/../todo", false},
+		{"./todo", false},
+		{".hidden", false},
+		{"work/.hidden/todo", false},
+		{"work//todo", false},
+		{"work/", false},
+	}
+	for _, test := range tests {
+		if valid := validNoteName(test.name); valid != test.valid {
+			t.Errorf("validNoteName(%q) = %v, want %v", test.name, valid, test.valid)
+		}
+	}
+}
+
+func TestImportShared(t *testing.T) {
+	useTempVault(t)
+	if err := WriteNote("todo", []byte("Buy milk"), testPassword); err != nil {
+		t.Fatal(err)
+	}
+	var shared strings.Builder
+	if err := ShareNote(NotePath("todo"), testPassword, "passphrase", &shared); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir("vault", 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("vault"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"../shared", "../../shared", "/tmp/shared", ".shared"} {
+		err := ImportShared(shared.String(), "passphrase", name, testPassword)
+		if err == nil || !strings.Contains(err.Error(), "invalid note name") {
+			t.Errorf("ImportShared with name %q: got error %v, want an invalid name", name, err)
+		}
+		if exists, _ := pathExists(NotePath(name)); exists && !filepath.IsAbs(name) {
+			t.Errorf("ImportShared with name %q wrote the note", name)
+		}
+	}
+
+	if err := ImportShared(shared.String(), "passphrase", "shared/todo", testPassword); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := OpenNote(NotePath("shared/todo"), testPassword); err != nil || content != "Buy milk" {
+		t.Errorf("got %q, %v, want the shared note", content, err)
+	}
+}
@@ -0,0 +1,102 @@
+package enotes
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+type SnippetKind string
+
+const (
+	// SnippetCode is the contents of a code block.
+	SnippetCode SnippetKind = "code"
+	// SnippetField is the value of a line like "username: alice" in a
+	// paragraph or list.
+	SnippetField SnippetKind = "field"
+)
+
+// Snippet is a part of a note body that can be copied on its own.
+type Snippet struct {
+	Kind SnippetKind
+	// Label is the language of a code block or the key of a field.
+	Label string
+	Text  string
+	// Line is the line number in the body where the snippet starts, starting
+	// at 1.
+	Line int
+	// Secret is set for the fields of secret blocks.
+	Secret bool
+}
+
+var fieldRegexp = regexp.MustCompile(`^\s*([\p{L}\p{N}_][\p{L}\p{N}_ .-]{0,39}):[ \t]+(\S.*?)\s*$`)
+
+// Snippets returns the code blocks and fields of body, the contents of a note
+// without its front matter, in the order they appear.
+func Snippets(body string) []Snippet {
+	source := []byte(body)
+	doc := markdown.Parser().Parse(text.NewReader(source))
+	line := func(offset int) int {
+		return strings.Count(body[:offset], "\n") + 1
+	}
+
+	var snippets []Snippet
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			lines := n.Lines()
+			if lines.Len() == 0 {
+				return ast.WalkSkipChildren, nil
+			}
+			var b strings.Builder
+			for i := 0; i < lines.Len(); i++ {
+				segment := lines.At(i)
+				b.Write(segment.Value(source))
+			}
+			snippet := Snippet{Kind: SnippetCode, Text: b.String(), Line: line(lines.At(0).Start)}
+			if fenced, ok := n.(*ast.FencedCodeBlock); ok {
+				snippet.Label = string(fenced.Language(source))
+			}
+			if snippet.Label != secretLanguage {
+				snippets = append(snippets, snippet)
+				return ast.WalkSkipChildren, nil
+			}
+			for i := 0; i < lines.Len(); i++ {
+				segment := lines.At(i)
+				if field, ok := parseSecretField(string(segment.Value(source))); ok {
+					snippets = append(snippets, Snippet{
+						Kind:   SnippetField,
+						Label:  field.Key,
+						Text:   field.Value,
+						Line:   line(segment.Start),
+						Secret: true,
+					})
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.Paragraph, *ast.TextBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				segment := lines.At(i)
+				m := fieldRegexp.FindStringSubmatch(string(segment.Value(source)))
+				if m == nil {
+					continue
+				}
+				snippets = append(snippets, Snippet{
+					Kind:  SnippetField,
+					Label: strings.TrimSpace(m[1]),
+					Text:  m[2],
+					Line:  line(segment.Start),
+				})
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return snippets
+}
@@ -0,0 +1,179 @@
+package enotes
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notes keep the secrets of two-factor authentication as otpauth:// URIs, the
+// format of the QR codes sites show, or in "totp: SECRET" fields with the
+// base32 secret, anywhere in the note or in a secret block. The codes are
+// computed locally as described in RFC 6238.
+
+// TOTP generates time-based one-time passwords.
+type TOTP struct {
+	// Label is the account name, or the issuer and account name separated
+	// by a colon.
+	Label  string
+	Issuer string
+	Secret []byte
+	// Algorithm is SHA1, SHA256 or SHA512.
+	Algorithm string
+	Digits    int
+	Period    time.Duration
+}
+
+var otpauthRegexp = regexp.MustCompile(`otpauth://totp/[^\s)>\]]+`)
+
+// ParseTOTP parses an otpauth://totp/ URI, or a base32 secret to generate
+// codes with the usual parameters.
+func ParseTOTP(s string) (TOTP, error) {
+	totp := TOTP{Algorithm: "SHA1", Digits: 6, Period: 30 * time.Second}
+	if !strings.HasPrefix(s, "otpauth://") {
+		secret, err := decodeTOTPSecret(s)
+		if err != nil {
+			return TOTP{}, err
+		}
+		totp.Secret = secret
+		return totp, nil
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return TOTP{}, err
+	}
+	if u.Host != "totp" {
+		return TOTP{}, fmt.Errorf("unsupported one-time password type %q", u.Host)
+	}
+	totp.Label = strings.TrimPrefix(u.Path, "/")
+	q := u.Query()
+	totp.Issuer = q.Get("issuer")
+	if totp.Secret, err = decodeTOTPSecret(q.Get("secret")); err != nil {
+		return TOTP{}, err
+	}
+	if algorithm := q.Get("algorithm"); algorithm != "" {
+		totp.Algorithm = strings.ToUpper(algorithm)
+		if _, err := totp.hash(); err != nil {
+			return TOTP{}, err
+		}
+	}
+	if digits := q.Get("digits"); digits != "" {
+		if totp.Digits, err = strconv.Atoi(digits); err != nil || totp.Digits < 6 || totp.Digits > 10 {
+			return TOTP{}, fmt.Errorf("invalid number of digits %q", digits)
+		}
+	}
+	if period := q.Get("period"); period != "" {
+		seconds, err := strconv.Atoi(period)
+		if err != nil || seconds <= 0 {
+			return TOTP{}, fmt.Errorf("invalid period %q", period)
+		}
+		totp.Period = time.Duration(seconds) * time.Second
+	}
+	return totp, nil
+}
+
+func decodeTOTPSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.TrimRight(strings.ReplaceAll(s, " ", ""), "="))
+	if s == "" {
+		return nil, errors.New("missing TOTP secret")
+	}
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid TOTP secret, it must be base32")
+	}
+	return secret, nil
+}
+
+func (t TOTP) hash() (func() hash.Hash, error) {
+	switch t.Algorithm {
+	case "SHA1":
+		return sha1.New, nil
+	case "SHA256":
+		return sha256.New, nil
+	case "SHA512":
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("unsupported TOTP algorithm %q", t.Algorithm)
+}
+
+// Code returns the code at time now, and how long it stays valid.
+func (t TOTP) Code(now time.Time) (string, time.Duration) {
+	period := int64(t.Period / time.Second)
+	counter := now.Unix() / period
+	remaining := time.Duration(period-now.Unix()%period) * time.Second
+
+	h, err := t.hash()
+	if err != nil {
+		// ParseTOTP checks the algorithm.
+		h = sha1.New
+	}
+	mac := hmac.New(h, t.Secret)
+	binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3.
+	offset := sum[len(sum)-1] & 0xf
+	value := int64(binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff)
+	mod := int64(1)
+	for i := 0; i < t.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", t.Digits, value%mod), remaining
+}
+
+// Name returns the issuer and account of t, or fallback if it has none.
+func (t TOTP) Name(fallback string) string {
+	switch {
+	case t.Label == "":
+		if t.Issuer != "" {
+			return t.Issuer
+		}
+		return fallback
+	case t.Issuer == "" || strings.HasPrefix(t.Label, t.Issuer+":"):
+		return t.Label
+	}
+	return t.Issuer + ": " + t.Label
+}
+
+// NoteTOTPs returns the one-time password generators in body, the contents of
+// a note without its front matter. Invalid ones are returned as errors, so
+// they can be reported without hiding the rest.
+func NoteTOTPs(body string) ([]TOTP, []error) {
+	var totps []TOTP
+	var errs []error
+	seen := map[string]bool{}
+	add := func(s string) {
+		if seen[s] {
+			return
+		}
+		seen[s] = true
+		totp, err := ParseTOTP(s)
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		totps = append(totps, totp)
+	}
+
+	for _, snippet := range Snippets(body) {
+		if snippet.Kind == SnippetField && strings.EqualFold(snippet.Label, "totp") {
+			add(snippet.Text)
+		}
+	}
+	for _, uri := range otpauthRegexp.FindAllString(body, -1) {
+		add(uri)
+	}
+	return totps, errs
+}
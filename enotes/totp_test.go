@@ -0,0 +1,52 @@
+package enotes
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTOTPCode checks the codes against the test vectors of RFC 6238,
+// appendix B.
+func TestTOTPCode(t *testing.T) {
+	secrets := map[string][]byte{
+		"SHA1":   []byte("12345678901234567890"),
+		"SHA256": []byte("12345678901234567890123456789012"),
+		"SHA512": []byte(strings.Repeat("1234567890", 6) + "1234"),
+	}
+	tests := []struct {
+		time      int64
+		algorithm string
+		code      string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1111111109, "SHA512", "25091201"},
+		{1111111111, "SHA1", "14050471"},
+		{1111111111, "SHA256", "67062674"},
+		{1111111111, "SHA512", "99943326"},
+		{1234567890, "SHA1", "89005924"},
+		{1234567890, "SHA256", "91819424"},
+		{1234567890, "SHA512", "93441116"},
+		{2000000000, "SHA1", "69279037"},
+		{2000000000, "SHA256", "90698825"},
+		{2000000000, "SHA512", "38618901"},
+		{20000000000, "SHA1", "65353130"},
+		{20000000000, "SHA256", "77737706"},
+		{20000000000, "SHA512", "47863826"},
+	}
+	for _, test := range tests {
+		totp := TOTP{
+			Secret:    secrets[test.algorithm],
+			Algorithm: test.algorithm,
+			Digits:    8,
+			Period:    30 * time.Second,
+		}
+		if code, _ := totp.Code(time.Unix(test.time, 0)); code != test.code {
+			t.Errorf("%s at %d: got %s, want %s", test.algorithm, test.time, code, test.code)
+		}
+	}
+}
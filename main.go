@@ -1,7 +1,15 @@
 package main
 
-import "github.com/zd4y/enotes/tui"
+import (
+	"os"
+
+	"github.com/zd4y/enotes/cli"
+	"github.com/zd4y/enotes/tui"
+)
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
 	tui.Run()
 }
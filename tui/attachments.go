@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	attachmentPromptNone = iota
+	attachmentPromptExtract
+	attachmentPromptAdd
+)
+
+func (m *model) toAttachments() tea.Cmd {
+	m.showingAttachments = true
+	m.attachmentIndex = 0
+	m.attachmentPrompt = attachmentPromptNone
+	m.attachmentStatus = ""
+	return listAttachments(m.notePath)
+}
+
+func (m *model) promptAttachmentPath(prompt int, placeholder string, value string) tea.Cmd {
+	m.attachmentPrompt = prompt
+	m.textInput = textinput.New()
+	m.textInput.Placeholder = placeholder
+	m.textInput.SetValue(value)
+	m.textInput.Focus()
+	return textinput.Blink
+}
+
+func attachmentsUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	name := m.noteName()
+
+	switch msg := msg.(type) {
+	case attachmentsMsg:
+		if msg.err != nil {
+			m.showingAttachments = false
+			m.fail("list attachments", name, msg.err)
+			return m, nil
+		}
+		m.attachments = msg.attachments
+		if m.attachmentIndex >= len(m.attachments) {
+			m.attachmentIndex = max(len(m.attachments)-1, 0)
+		}
+		return m, nil
+	case attachFileMsg:
+		if msg.err != nil {
+			m.fail("attach file", name, msg.err)
+			return m, nil
+		}
+		m.attachmentStatus = "Attached " + msg.name
+		m.loadingNote = true
+		return m, tea.Batch(listAttachments(m.notePath), openNote(m.notePath, m.password))
+	case extractAttachmentMsg:
+		if msg.err != nil {
+			m.fail("extract attachment", name, msg.err)
+			return m, nil
+		}
+		m.attachmentStatus = "Extracted to " + msg.path
+		return m, nil
+	case viewerFinishedMsg:
+		m.viewerActive = false
+		if msg.cleanup != nil {
+			m.tempCleanups = append(m.tempCleanups, msg.cleanup)
+		}
+		if msg.err != nil {
+			m.fail("open attachment", name, msg.err)
+		}
+		return m, nil
+	case openNoteMsg:
+		// The note changes when a file is attached, keep it up to date.
+		return noteUpdate(msg, m)
+	case tea.KeyMsg:
+		if m.viewerActive {
+			return m, nil
+		}
+		if m.attachmentPrompt != attachmentPromptNone {
+			return attachmentPromptUpdate(msg, m)
+		}
+		switch {
+		case key.Matches(msg, m.keys.Back):
+			m.showingAttachments = false
+			return m, nil
+		case key.Matches(msg, m.keys.Up):
+			if m.attachmentIndex > 0 {
+				m.attachmentIndex--
+			}
+		case key.Matches(msg, m.keys.Down):
+			if m.attachmentIndex < len(m.attachments)-1 {
+				m.attachmentIndex++
+			}
+		case key.Matches(msg, m.keys.AddAttachment):
+			cmd := m.promptAttachmentPath(attachmentPromptAdd, "Path of the file to attach", "")
+			return m, cmd
+		case key.Matches(msg, m.keys.OpenAttachment):
+			if len(m.attachments) > 0 {
+				m.viewerActive = true
+				attachment := m.attachments[m.attachmentIndex].Name
+				return m, openAttachment(m.notePath, attachment, m.password, m.config.Viewer)
+			}
+		case key.Matches(msg, m.keys.ExtractAttachment):
+			if len(m.attachments) > 0 {
+				attachment := m.attachments[m.attachmentIndex].Name
+				dst := attachment
+				if home, err := os.UserHomeDir(); err == nil {
+					dst = filepath.Join(home, attachment)
+				}
+				cmd := m.promptAttachmentPath(attachmentPromptExtract, "Extract to", dst)
+				return m, cmd
+			}
+		}
+	}
+	return m, nil
+}
+
+func attachmentPromptUpdate(msg tea.KeyMsg, m model) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.attachmentPrompt = attachmentPromptNone
+		return m, nil
+	case key.Matches(msg, m.keys.Confirm):
+		path := m.textInput.Value()
+		prompt := m.attachmentPrompt
+		m.attachmentPrompt = attachmentPromptNone
+		if path == "" {
+			return m, nil
+		}
+		if prompt == attachmentPromptAdd {
+			m.attachmentStatus = "Attaching " + path
+			return m, attachFile(m.notePath, path, m.password)
+		}
+		attachment := m.attachments[m.attachmentIndex].Name
+		m.attachmentStatus = "Extracting " + attachment
+		return m, extractAttachment(m.notePath, attachment, path, m.password)
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func attachmentsView(m model) string {
+	if m.viewerActive {
+		return fmt.Sprintf("%s Opening attachment\n", m.spinner.View())
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Attachments of " + m.noteName()))
+	b.WriteString("\n\n")
+
+	if len(m.attachments) == 0 {
+		b.WriteString("No attachments\n")
+	}
+	for i, a := range m.attachments {
+		line := fmt.Sprintf("%s %s", a.Name, descStyle.Render(a.ModTime.Format(time.Stamp)))
+		if i == m.attachmentIndex {
+			b.WriteString(bold.Render("> ") + line + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	b.WriteString("\n")
+
+	switch m.attachmentPrompt {
+	case attachmentPromptAdd:
+		b.WriteString("Attach file:\n\n" + m.textInput.View() + "\n\n" + m.promptHelp("attach"))
+		return docStyle.Render(b.String())
+	case attachmentPromptExtract:
+		b.WriteString("Extract to:\n\n" + m.textInput.View() + "\n\n" +
+			bold.Render("The extracted file is not encrypted.") + "\n\n" + m.promptHelp("extract"))
+		return docStyle.Render(b.String())
+	}
+
+	if m.attachmentStatus != "" {
+		b.WriteString(m.attachmentStatus + "\n\n")
+	}
+	b.WriteString(joinHelp(
+		bindingsHelp("open", m.keys.OpenAttachment),
+		bindingsHelp("extract", m.keys.ExtractAttachment),
+		bindingsHelp("attach file", m.keys.AddAttachment),
+		bindingsHelp("go back", m.keys.Back),
+	))
+	return docStyle.Render(b.String())
+}
+
+// promptHelp returns the keys to confirm or cancel a prompt that does action.
+func (m model) promptHelp(action string) string {
+	return fmt.Sprintf("(%s to %s, %s to cancel)", m.keys.Confirm.Help().Key, action, m.keys.Cancel.Help().Key)
+}
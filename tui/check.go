@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func checkUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case checkMsg:
+		if msg.err != nil {
+			m.checking = false
+			m.fail("check notes", "", msg.err)
+			return m, nil
+		}
+		m.checkReport = msg.report
+	case tea.KeyMsg:
+		if key.Matches(msg, m.keys.Back) && m.checkReport != nil {
+			m.checking = false
+			m.checkReport = nil
+		}
+	}
+	return m, nil
+}
+
+func checkView(m model) string {
+	if m.checkReport == nil {
+		return fmt.Sprintf("%s Checking notes\n", m.spinner.View())
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Check"))
+	b.WriteString("\n\n")
+	fmt.Fprintf(&b, "%d notes checked, %d ok\n\n", m.checkReport.Notes, m.checkReport.OK)
+	if m.checkReport.Healthy() {
+		b.WriteString("No problems found\n")
+	}
+	for _, issue := range m.checkReport.Issues {
+		fmt.Fprintf(&b, "%s %s\n  %s\n", bold.Render(string(issue.Kind)), issue.Path, descStyle.Render(issue.Detail))
+	}
+	b.WriteString("\n")
+	b.WriteString(bindingsHelp("go back", m.keys.Back))
+	return docStyle.Render(b.String())
+}
@@ -0,0 +1,239 @@
+package tui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zd4y/enotes/enotes"
+)
+
+// Text copied from notes is cleared from the clipboard after the timeout in
+// the configuration and when enotes exits, unless something else was copied
+// in the meantime. Without a system clipboard, like over SSH, the text is
+// copied through the terminal with an OSC 52 escape sequence, and then it
+// can't be checked before clearing it. The sequence is written to the output
+// of the program while it is paused, so it isn't mixed with the interface
+// being drawn.
+
+var copied struct {
+	sync.Mutex
+	text  string
+	osc52 bool
+	// seq counts the copies, so only the timeout of the last one clears it.
+	seq int
+}
+
+type clipboardMsg struct {
+	what string
+	seq  int
+	err  error
+}
+
+type clearClipboardMsg struct {
+	seq int
+}
+
+type clipboardClearedMsg struct {
+	err error
+}
+
+func copyToClipboard(text string, what string) tea.Cmd {
+	return func() tea.Msg {
+		if err := clipboard.WriteAll(text); err == nil {
+			return copiedMsg(text, what, false)
+		}
+		return tea.Exec(newTerminalCommand(func(out io.Writer) error {
+			return writeOSC52(out, text)
+		}), func(err error) tea.Msg {
+			if err != nil {
+				return clipboardMsg{what: what, err: err}
+			}
+			return copiedMsg(text, what, true)
+		})()
+	}
+}
+
+// copiedMsg records that text was copied, through the terminal if osc52 is
+// set.
+func copiedMsg(text string, what string, osc52 bool) tea.Msg {
+	copied.Lock()
+	defer copied.Unlock()
+
+	copied.text = text
+	copied.osc52 = osc52
+	copied.seq++
+	return clipboardMsg{what, copied.seq, nil}
+}
+
+// clearClipboardCmd clears the text copied from a note from the clipboard if
+// seq is still the last copy.
+func clearClipboardCmd(seq int) tea.Cmd {
+	return func() tea.Msg {
+		copied.Lock()
+		pending := copied.text != "" && seq == copied.seq
+		osc52 := copied.osc52
+		copied.Unlock()
+		if !pending {
+			return clipboardClearedMsg{}
+		}
+		if !osc52 {
+			return clipboardClearedMsg{clearClipboard(seq, nil)}
+		}
+		return tea.Exec(newTerminalCommand(func(out io.Writer) error {
+			return clearClipboard(seq, out)
+		}), func(err error) tea.Msg {
+			return clipboardClearedMsg{err}
+		})()
+	}
+}
+
+func writeOSC52(out io.Writer, text string) error {
+	_, err := fmt.Fprintf(out, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
+
+// clearClipboard clears the text copied from a note from the clipboard, if
+// seq is the last copy or -1. Text copied through the terminal is cleared
+// writing to out.
+func clearClipboard(seq int, out io.Writer) error {
+	copied.Lock()
+	defer copied.Unlock()
+
+	if copied.text == "" || (seq != -1 && seq != copied.seq) {
+		return nil
+	}
+	text := copied.text
+	copied.text = ""
+	if copied.osc52 {
+		return writeOSC52(out, "")
+	}
+	if current, err := clipboard.ReadAll(); err != nil || current != text {
+		return err
+	}
+	return clipboard.WriteAll("")
+}
+
+// terminalCommand is run with tea.Exec to write to the output of the program
+// while it is paused.
+type terminalCommand struct {
+	write func(out io.Writer) error
+	out   io.Writer
+}
+
+func newTerminalCommand(write func(out io.Writer) error) *terminalCommand {
+	return &terminalCommand{write: write, out: os.Stdout}
+}
+
+func (c *terminalCommand) Run() error {
+	return c.write(c.out)
+}
+
+func (c *terminalCommand) SetStdout(out io.Writer) {
+	c.out = out
+}
+
+func (c *terminalCommand) SetStdin(io.Reader)  {}
+func (c *terminalCommand) SetStderr(io.Writer) {}
+
+func clipboardUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case clipboardMsg:
+		if msg.err != nil {
+			m.fail("copy", m.noteName(), msg.err)
+			return m, nil
+		}
+		m.clipboardStatus = "Copied " + msg.what
+		if m.config.ClipboardTimeout <= 0 {
+			return m, nil
+		}
+		timeout := time.Duration(m.config.ClipboardTimeout) * time.Second
+		m.clipboardStatus += fmt.Sprintf(", clearing in %s", timeout)
+		return m, tea.Tick(timeout, func(time.Time) tea.Msg {
+			return clearClipboardMsg{msg.seq}
+		})
+	case clearClipboardMsg:
+		return m, clearClipboardCmd(msg.seq)
+	case clipboardClearedMsg:
+		if msg.err != nil {
+			m.fail("clear clipboard", "", msg.err)
+		}
+	}
+	return m, nil
+}
+
+func (m *model) toSnippets() {
+	m.snippets = enotes.Snippets(m.noteBody)
+	m.snippetIndex = 0
+	m.pickingSnippet = true
+}
+
+func snippetsUpdate(msg tea.KeyMsg, m model) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Back, m.keys.CopyBlock):
+		m.pickingSnippet = false
+	case key.Matches(msg, m.keys.Up):
+		if m.snippetIndex > 0 {
+			m.snippetIndex--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if m.snippetIndex < len(m.snippets)-1 {
+			m.snippetIndex++
+		}
+	case key.Matches(msg, m.keys.CopyValue):
+		if len(m.snippets) > 0 {
+			m.pickingSnippet = false
+			snippet := m.snippets[m.snippetIndex]
+			what := "code block"
+			if snippet.Kind == enotes.SnippetField {
+				what = snippet.Label
+			}
+			return m, copyToClipboard(snippet.Text, what)
+		}
+	}
+	return m, nil
+}
+
+// snippetsView lists the code blocks and fields of the note that can be
+// copied, in place of the note.
+func (m model) snippetsView() string {
+	width := m.noteWidth() - 2
+	var lines []string
+	if len(m.snippets) == 0 {
+		lines = append(lines, descStyle.Render("No code blocks or fields to copy"))
+	}
+	for i, snippet := range m.snippets {
+		label := snippet.Label
+		text := snippet.Text
+		if snippet.Secret {
+			text = mask
+		}
+		if snippet.Kind == enotes.SnippetCode {
+			if label == "" {
+				label = "code"
+			}
+			text = strings.ReplaceAll(strings.TrimSpace(text), "\n", " ⏎ ")
+		}
+		line := []rune(fmt.Sprintf("%s: %s", label, text))
+		if len(line) > width {
+			line = append(line[:width-1], '…')
+		}
+		if i == m.snippetIndex {
+			lines = append(lines, bold.Render("> ")+selectedLinkStyle.Render(string(line)))
+		} else {
+			lines = append(lines, "  "+string(line))
+		}
+	}
+
+	first := max(min(m.snippetIndex-m.noteViewport.Height/2, len(lines)-m.noteViewport.Height), 0)
+	lines = lines[first:min(len(lines), first+m.noteViewport.Height)]
+	return lipgloss.NewStyle().Height(m.noteViewport.Height).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// opError is an error that happened while performing an operation, with enough
+// context to tell the user what failed and on which note.
+type opError struct {
+	op   string
+	note string
+	err  error
+	at   time.Time
+}
+
+func (e *opError) Error() string {
+	if e.note != "" {
+		return fmt.Sprintf("%s %q: %v", e.op, e.note, e.err)
+	}
+	return fmt.Sprintf("%s: %v", e.op, e.err)
+}
+
+func (e *opError) Unwrap() error {
+	return e.err
+}
+
+// fail shows the error screen for err and records it in the session error log.
+// Callers are expected to leave the model in the screen the user should return
+// to once the error is dismissed.
+func (m *model) fail(op, note string, err error) {
+	m.err = m.logError(op, note, err)
+}
+
+// logError records err in the session error log without showing it, for
+// errors that don't stop the user from going on.
+func (m *model) logError(op, note string, err error) *opError {
+	e := &opError{op: op, note: note, err: err, at: time.Now()}
+	m.errLog = append(m.errLog, e)
+	return e
+}
+
+func errorUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if key.Matches(msg, m.keys.Back, m.keys.Confirm) {
+			m.err = nil
+		}
+	}
+	return m, nil
+}
+
+func errorView(m model) string {
+	var b strings.Builder
+	b.WriteString(errorTitleStyle.Render("Error"))
+	b.WriteString("\n\n")
+	b.WriteString(bold.Render("Operation: "))
+	b.WriteString(m.err.op)
+	b.WriteString("\n")
+	if m.err.note != "" {
+		b.WriteString(bold.Render("Note: "))
+		b.WriteString(m.err.note)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(m.err.err.Error())
+	b.WriteString("\n\n")
+	b.WriteString(joinHelp(
+		bindingsHelp("dismiss", m.keys.Confirm, m.keys.Back),
+		bindingsHelp("quit", m.keys.Quit),
+	))
+	return docStyle.Render(b.String())
+}
+
+func errorLogUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if key.Matches(msg, m.keys.Back, m.keys.ErrorLog) {
+			m.showingErrLog = false
+		}
+	}
+	return m, nil
+}
+
+func errorLogView(m model) string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Error log"))
+	b.WriteString("\n\n")
+	if len(m.errLog) == 0 {
+		b.WriteString("No errors in this session\n")
+	}
+	for _, e := range m.errLog {
+		b.WriteString(keyStyle.Render(e.at.Format(time.Stamp)))
+		b.WriteString(" ")
+		b.WriteString(e.Error())
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(bindingsHelp("go back", m.keys.Back))
+	return docStyle.Render(b.String())
+}
+
+// dismissHelp returns the keys to leave a screen that shows a result.
+func (m model) dismissHelp() string {
+	return fmt.Sprintf("(%s or %s to go back)", m.keys.Confirm.Help().Key, m.keys.Back.Help().Key)
+}
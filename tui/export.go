@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zd4y/enotes/enotes"
+)
+
+func (m *model) toExportHTML() tea.Cmd {
+	m.textInput = textinput.New()
+	m.textInput.Placeholder = "Directory"
+	if home, err := os.UserHomeDir(); err == nil {
+		m.textInput.SetValue(filepath.Join(home, "enotes-html"))
+	}
+	m.textInput.Focus()
+	m.exporting = true
+	return textinput.Blink
+}
+
+func exportUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case exportMsg:
+		m.exportRunning = false
+		if msg.err != nil {
+			m.exporting = false
+			m.fail("export notes", "", msg.err)
+			return m, nil
+		}
+		m.exportResult = fmt.Sprintf("%d notes exported to %s", msg.n, msg.dir)
+		return m, nil
+	case tea.KeyMsg:
+		if m.exportRunning {
+			return m, nil
+		}
+		switch {
+		case key.Matches(msg, m.keys.Cancel):
+			m.exporting = false
+			m.exportResult = ""
+			return m, nil
+		case key.Matches(msg, m.keys.Confirm):
+			if m.exportResult != "" {
+				m.exporting = false
+				m.exportResult = ""
+				return m, nil
+			}
+			m.exportRunning = true
+			return m, exportNotes(m.textInput.Value(), m.password, enotes.ExportHTML)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func exportView(m model) string {
+	if m.exportRunning {
+		return fmt.Sprintf("%s Exporting notes\n", m.spinner.View())
+	}
+	if m.exportResult != "" {
+		return m.exportResult + "\n\n" + m.dismissHelp() + "\n"
+	}
+	return fmt.Sprintf(
+		"Export notes as HTML to which directory?\n\n%s\n\n%s\n\n(%s to cancel)\n",
+		m.textInput.View(),
+		bold.Render("The exported pages are not encrypted."),
+		m.keys.Cancel.Help().Key,
+	)
+}
@@ -1,46 +1,73 @@
 package tui
 
 import (
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 func fileListUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg := msg.String(); msg {
-		case "q":
+		if m.list.SettingFilter() {
+			break
+		}
+		switch {
+		case key.Matches(msg, m.keys.Back):
 			m.quitting = true
 			return m, nil
-		case "esc":
-			if !m.list.SettingFilter() {
-				m.quitting = true
-				return m, nil
-			}
-		case "enter":
-			if !m.list.SettingFilter() {
-				index := m.list.Index()
-				if index == 0 {
-					m.textInput = textinput.New()
-					m.textInput.Placeholder = "New note name (leave empty for current date)"
-					m.textInput.Focus()
-					m.toNewNote()
-					return m, textinput.Blink
-				} else {
-					m.toNote(index)
-					item := m.list.SelectedItem().(fileItem)
-					m.loadingNote = true
-					return m, openNote(item.file.Name(), m.password)
-				}
+		case key.Matches(msg, m.keys.Check):
+			m.checking = true
+			return m, checkNotes(m.password)
+		case key.Matches(msg, m.keys.ExportHTML):
+			cmd := m.toExportHTML()
+			return m, cmd
+		case key.Matches(msg, m.keys.Import):
+			cmd := m.toReceive()
+			return m, cmd
+		case key.Matches(msg, m.keys.ErrorLog):
+			m.showingErrLog = true
+			return m, nil
+		case key.Matches(msg, m.keys.Search):
+			cmd := m.toSearch()
+			return m, cmd
+		case key.Matches(msg, m.keys.Preview):
+			cmd := m.togglePreview()
+			return m, cmd
+		case key.Matches(msg, m.keys.Sort):
+			cmd := m.cycleSort(false)
+			return m, cmd
+		case key.Matches(msg, m.keys.ReverseSort):
+			cmd := m.cycleSort(true)
+			return m, cmd
+		case key.Matches(msg, m.keys.Pin):
+			cmd := m.togglePin()
+			return m, cmd
+		case key.Matches(msg, m.keys.Confirm):
+			item, ok := m.selectedNote()
+			if !ok {
+				m.textInput = textinput.New()
+				m.textInput.Placeholder = "New note name (leave empty for current date)"
+				m.textInput.Focus()
+				m.toNewNote()
+				return m, textinput.Blink
+			} else {
+				cmd := m.openNoteAt(item.path)
+				return m, cmd
 			}
 		}
 	}
 
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
-	return m, cmd
+	previewCmd := m.updatePreview()
+	return m, tea.Batch(cmd, previewCmd)
 }
 
 func fileListView(m model) string {
+	if m.showingPreview {
+		return lipgloss.JoinHorizontal(lipgloss.Top, docStyle.Render(m.list.View()), m.previewView())
+	}
 	return docStyle.Render(m.list.View())
 }
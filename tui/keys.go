@@ -0,0 +1,412 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// keyMap holds every key binding of the interface. The defaults can be
+// changed in the "keys" object of the configuration, which maps the names in
+// keyMap.named to the list of keys to use instead.
+type keyMap struct {
+	Quit         key.Binding
+	Help         key.Binding
+	Up           key.Binding
+	Down         key.Binding
+	PageUp       key.Binding
+	PageDown     key.Binding
+	HalfPageUp   key.Binding
+	HalfPageDown key.Binding
+	Confirm      key.Binding
+	Cancel       key.Binding
+	Back         key.Binding
+	Toggle       key.Binding
+	Reveal       key.Binding
+	CopyValue    key.Binding
+
+	Check       key.Binding
+	ExportHTML  key.Binding
+	Import      key.Binding
+	ErrorLog    key.Binding
+	Search      key.Binding
+	Preview     key.Binding
+	Sort        key.Binding
+	ReverseSort key.Binding
+	Pin         key.Binding
+
+	Find             key.Binding
+	NextMatch        key.Binding
+	PrevMatch        key.Binding
+	Edit             key.Binding
+	Raw              key.Binding
+	Copy             key.Binding
+	CopyBlock        key.Binding
+	Secrets          key.Binding
+	GeneratePassword key.Binding
+	NextLink         key.Binding
+	PrevLink         key.Binding
+	FollowLink       key.Binding
+	HistoryBack      key.Binding
+	HistoryForward   key.Binding
+	Outline          key.Binding
+	Fold             key.Binding
+	FoldAll          key.Binding
+	Attachments      key.Binding
+	Share            key.Binding
+
+	OpenAttachment    key.Binding
+	ExtractAttachment key.Binding
+	AddAttachment     key.Binding
+
+	SearchMode key.Binding
+	PrevResult key.Binding
+	NextResult key.Binding
+	NextField  key.Binding
+	PrevField  key.Binding
+}
+
+func binding(keys []string, help string, desc string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(help, desc))
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Quit:         binding([]string{"ctrl+c"}, "ctrl+c", "quit"),
+		Help:         binding([]string{"?"}, "?", "help"),
+		Up:           binding([]string{"up", "k"}, "↑/k", "up"),
+		Down:         binding([]string{"down", "j"}, "↓/j", "down"),
+		PageUp:       binding([]string{"pgup", "b"}, "pgup/b", "page up"),
+		PageDown:     binding([]string{"pgdown", " ", "f"}, "pgdn/f", "page down"),
+		HalfPageUp:   binding([]string{"u", "ctrl+u"}, "u", "half page up"),
+		HalfPageDown: binding([]string{"d", "ctrl+d"}, "d", "half page down"),
+		Confirm:      binding([]string{"enter"}, "enter", "confirm"),
+		Cancel:       binding([]string{"esc"}, "esc", "cancel"),
+		Back:         binding([]string{"q", "esc"}, "q/esc", "go back"),
+		Toggle:       binding([]string{" "}, "space", "fold"),
+		Reveal:       binding([]string{" ", "r"}, "space/r", "reveal"),
+		CopyValue:    binding([]string{"enter", "y"}, "enter/y", "copy"),
+
+		Check:       binding([]string{"C"}, "C", "check notes"),
+		ExportHTML:  binding([]string{"H"}, "H", "export as HTML"),
+		Import:      binding([]string{"I"}, "I", "import shared note"),
+		ErrorLog:    binding([]string{"L"}, "L", "error log"),
+		Search:      binding([]string{"S"}, "S", "search"),
+		Preview:     binding([]string{"v"}, "v", "preview"),
+		Sort:        binding([]string{"s"}, "s", "sort by"),
+		ReverseSort: binding([]string{"r"}, "r", "reverse order"),
+		Pin:         binding([]string{"p"}, "p", "pin/unpin"),
+
+		Find:             binding([]string{"/"}, "/", "search"),
+		NextMatch:        binding([]string{"n"}, "n", "next match"),
+		PrevMatch:        binding([]string{"N"}, "N", "previous match"),
+		Edit:             binding([]string{"e"}, "e", "edit note"),
+		Raw:              binding([]string{"r"}, "r", "raw/rendered"),
+		Copy:             binding([]string{"y"}, "y", "copy note"),
+		CopyBlock:        binding([]string{"c"}, "c", "copy block/field"),
+		Secrets:          binding([]string{"s"}, "s", "secrets"),
+		GeneratePassword: binding([]string{"g"}, "g", "generate password"),
+		NextLink:         binding([]string{"tab"}, "tab", "next link"),
+		PrevLink:         binding([]string{"shift+tab"}, "shift+tab", "previous link"),
+		FollowLink:       binding([]string{"enter"}, "enter", "follow link"),
+		HistoryBack:      binding([]string{"["}, "[", "back"),
+		HistoryForward:   binding([]string{"]"}, "]", "forward"),
+		Outline:          binding([]string{"o"}, "o", "outline"),
+		Fold:             binding([]string{"z"}, "z", "fold"),
+		FoldAll:          binding([]string{"Z"}, "Z", "fold all"),
+		Attachments:      binding([]string{"a"}, "a", "attachments"),
+		Share:            binding([]string{"x"}, "x", "share"),
+
+		OpenAttachment:    binding([]string{"enter", "o"}, "enter/o", "open"),
+		ExtractAttachment: binding([]string{"x"}, "x", "extract"),
+		AddAttachment:     binding([]string{"A"}, "A", "attach file"),
+
+		SearchMode: binding([]string{"tab"}, "tab", "search mode"),
+		PrevResult: binding([]string{"up", "ctrl+p"}, "↑", "previous result"),
+		NextResult: binding([]string{"down", "ctrl+n"}, "↓", "next result"),
+		NextField:  binding([]string{"tab", "down"}, "tab", "next field"),
+		PrevField:  binding([]string{"shift+tab", "up"}, "shift+tab", "previous field"),
+	}
+}
+
+// named returns the bindings of km by their names in the configuration.
+func (km *keyMap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"quit":              &km.Quit,
+		"help":              &km.Help,
+		"up":                &km.Up,
+		"down":              &km.Down,
+		"pageUp":            &km.PageUp,
+		"pageDown":          &km.PageDown,
+		"halfPageUp":        &km.HalfPageUp,
+		"halfPageDown":      &km.HalfPageDown,
+		"confirm":           &km.Confirm,
+		"cancel":            &km.Cancel,
+		"back":              &km.Back,
+		"toggle":            &km.Toggle,
+		"reveal":            &km.Reveal,
+		"copyValue":         &km.CopyValue,
+		"check":             &km.Check,
+		"exportHTML":        &km.ExportHTML,
+		"import":            &km.Import,
+		"errorLog":          &km.ErrorLog,
+		"search":            &km.Search,
+		"preview":           &km.Preview,
+		"sort":              &km.Sort,
+		"reverseSort":       &km.ReverseSort,
+		"pin":               &km.Pin,
+		"find":              &km.Find,
+		"nextMatch":         &km.NextMatch,
+		"prevMatch":         &km.PrevMatch,
+		"edit":              &km.Edit,
+		"raw":               &km.Raw,
+		"copy":              &km.Copy,
+		"copyBlock":         &km.CopyBlock,
+		"secrets":           &km.Secrets,
+		"generatePassword":  &km.GeneratePassword,
+		"nextLink":          &km.NextLink,
+		"prevLink":          &km.PrevLink,
+		"followLink":        &km.FollowLink,
+		"historyBack":       &km.HistoryBack,
+		"historyForward":    &km.HistoryForward,
+		"outline":           &km.Outline,
+		"fold":              &km.Fold,
+		"foldAll":           &km.FoldAll,
+		"attachments":       &km.Attachments,
+		"share":             &km.Share,
+		"openAttachment":    &km.OpenAttachment,
+		"extractAttachment": &km.ExtractAttachment,
+		"addAttachment":     &km.AddAttachment,
+		"searchMode":        &km.SearchMode,
+		"prevResult":        &km.PrevResult,
+		"nextResult":        &km.NextResult,
+		"nextField":         &km.NextField,
+		"prevField":         &km.PrevField,
+	}
+}
+
+// keyViews are the names of the bindings used together in each view, where
+// two of them with the same key would shadow one another.
+var keyViews = []struct {
+	name     string
+	bindings []string
+}{
+	{"notes list", []string{
+		"quit", "help", "up", "down", "find", "back", "confirm", "check", "exportHTML",
+		"import", "errorLog", "search", "preview", "sort", "reverseSort", "pin",
+	}},
+	{"note view", []string{
+		"quit", "help", "back", "cancel", "up", "down", "pageUp", "pageDown", "halfPageUp",
+		"halfPageDown", "find", "nextMatch", "prevMatch", "edit", "raw", "copy", "copyBlock",
+		"secrets", "generatePassword", "nextLink", "prevLink", "followLink", "historyBack",
+		"historyForward", "outline", "fold", "foldAll", "attachments", "share",
+	}},
+	{"outline", []string{"quit", "up", "down", "confirm", "cancel", "outline", "toggle", "fold"}},
+	{"secrets", []string{"quit", "back", "secrets", "up", "down", "reveal", "copyValue"}},
+	{"blocks to copy", []string{"quit", "back", "copyBlock", "up", "down", "copyValue"}},
+	{"attachments", []string{
+		"quit", "back", "cancel", "confirm", "up", "down", "openAttachment",
+		"extractAttachment", "addAttachment",
+	}},
+	{"search", []string{"quit", "cancel", "confirm", "searchMode", "prevResult", "nextResult"}},
+	{"forms", []string{"quit", "back", "cancel", "confirm", "nextField", "prevField"}},
+}
+
+// newKeyMap returns the default bindings with the keys in overrides, which
+// maps binding names to keys. An empty list of keys disables the binding.
+// Overrides that give two bindings of the same view a key they don't share by
+// default are reported, since one of them would never be used.
+func newKeyMap(overrides map[string][]string) (keyMap, error) {
+	km := defaultKeyMap()
+	named := km.named()
+	var unknown []string
+	for name, keys := range overrides {
+		b, ok := named[name]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		b.SetKeys(keys...)
+		b.SetHelp(keysHelp(keys), b.Help().Desc)
+		b.SetEnabled(len(keys) > 0)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return keyMap{}, fmt.Errorf("unknown key bindings in the configuration: %s", strings.Join(unknown, ", "))
+	}
+	if conflicts := km.conflicts(); len(conflicts) > 0 {
+		return keyMap{}, fmt.Errorf("conflicting key bindings in the configuration: %s", strings.Join(conflicts, "; "))
+	}
+	return km, nil
+}
+
+// conflicts describes the keys that two bindings of the same view share in km
+// but not in the default bindings.
+func (km *keyMap) conflicts() []string {
+	defaults := defaultKeyMap()
+	named, defaultNamed := km.named(), defaults.named()
+	var conflicts []string
+	for _, view := range keyViews {
+		for i, a := range view.bindings {
+			for _, b := range view.bindings[i+1:] {
+				for _, k := range sharedKeys(*named[a], *named[b]) {
+					if hasKey(*defaultNamed[a], k) && hasKey(*defaultNamed[b], k) {
+						continue
+					}
+					conflicts = append(conflicts, fmt.Sprintf("%s and %s use %s in the %s", a, b, keysHelp([]string{k}), view.name))
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// sharedKeys returns the keys of a that b uses too.
+func sharedKeys(a, b key.Binding) []string {
+	if !a.Enabled() {
+		return nil
+	}
+	var shared []string
+	for _, k := range a.Keys() {
+		if hasKey(b, k) {
+			shared = append(shared, k)
+		}
+	}
+	return shared
+}
+
+func hasKey(b key.Binding, k string) bool {
+	if !b.Enabled() {
+		return false
+	}
+	for _, bk := range b.Keys() {
+		if bk == k {
+			return true
+		}
+	}
+	return false
+}
+
+// keysHelp returns how keys are shown in the help.
+func keysHelp(keys []string) string {
+	shown := make([]string, len(keys))
+	for i, k := range keys {
+		switch k {
+		case " ":
+			k = "space"
+		case "up":
+			k = "↑"
+		case "down":
+			k = "↓"
+		}
+		shown[i] = k
+	}
+	return strings.Join(shown, "/")
+}
+
+// withDesc returns b with desc as its description in the help.
+func withDesc(b key.Binding, desc string) key.Binding {
+	b.SetHelp(b.Help().Key, desc)
+	return b
+}
+
+// bindingsHelp returns the help for the keys of bindings, which do the same
+// thing, or an empty string if they are all disabled.
+func bindingsHelp(desc string, bindings ...key.Binding) string {
+	var keys []string
+	for _, b := range bindings {
+		if b.Enabled() {
+			keys = append(keys, b.Help().Key)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	return help(strings.Join(keys, "/"), desc)
+}
+
+// joinHelp joins the help of several bindings, leaving out the empty ones.
+func joinHelp(items ...string) string {
+	shown := items[:0]
+	for _, item := range items {
+		if item != "" {
+			shown = append(shown, item)
+		}
+	}
+	return strings.Join(shown, dot)
+}
+
+type keyGroup struct {
+	title    string
+	bindings []key.Binding
+}
+
+// groups returns the bindings of km grouped for the help overlay.
+func (km keyMap) groups() []keyGroup {
+	return []keyGroup{
+		{"General", []key.Binding{
+			km.Up, km.Down, km.PageUp, km.PageDown, km.HalfPageUp, km.HalfPageDown,
+			km.Confirm, km.Cancel, km.Back, km.Help, km.Quit,
+		}},
+		{"Notes", []key.Binding{
+			km.Check, km.ExportHTML, km.Import, km.ErrorLog, km.Search, km.SearchMode, km.Preview,
+			km.Sort, km.ReverseSort, km.Pin,
+		}},
+		{"Reading", []key.Binding{
+			km.Find, km.NextMatch, km.PrevMatch, km.Raw, km.NextLink, km.PrevLink, km.FollowLink,
+			km.HistoryBack, km.HistoryForward, km.Outline, km.Fold, km.FoldAll,
+		}},
+		{"Note", []key.Binding{
+			km.Edit, km.Copy, km.CopyBlock, km.Secrets, km.GeneratePassword, km.Attachments,
+			km.Share,
+		}},
+		{"Lists and forms", []key.Binding{
+			km.Toggle, km.Reveal, km.CopyValue, km.OpenAttachment, km.ExtractAttachment,
+			km.AddAttachment, km.NextField, km.PrevField,
+		}},
+	}
+}
+
+// helpView lists the active key bindings in columns, in place of the note.
+func (m model) helpView() string {
+	const gap = "    "
+	width := m.noteWidth()
+	var rows, row []string
+	rowWidth := 0
+	for _, group := range m.keys.groups() {
+		keyWidth := 0
+		for _, b := range group.bindings {
+			if b.Enabled() {
+				keyWidth = max(keyWidth, lipgloss.Width(b.Help().Key))
+			}
+		}
+		lines := []string{bold.Render(group.title)}
+		for _, b := range group.bindings {
+			if b.Enabled() {
+				k := b.Help().Key
+				k += strings.Repeat(" ", keyWidth-lipgloss.Width(k))
+				lines = append(lines, keyStyle.Render(k)+" "+descStyle.Render(b.Help().Desc))
+			}
+		}
+		column := strings.Join(lines, "\n")
+		columnWidth := lipgloss.Width(column)
+		if len(row) > 0 && rowWidth+len(gap)+columnWidth > width {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+			row, rowWidth = nil, 0
+		}
+		if len(row) > 0 {
+			row = append(row, gap)
+			rowWidth += len(gap)
+		}
+		row = append(row, column)
+		rowWidth += columnWidth
+	}
+	rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+
+	lines := strings.Split(strings.Join(rows, "\n\n"), "\n")
+	lines = lines[:min(len(lines), m.noteViewport.Height)]
+	return lipgloss.NewStyle().Height(m.noteViewport.Height).Render(strings.Join(lines, "\n"))
+}
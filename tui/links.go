@@ -0,0 +1,250 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zd4y/enotes/enotes"
+)
+
+// stripANSI removes the SGR escape sequences glamour renders from s. It is
+// called on whole rendered notes, for which a regular expression is too slow.
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b[") {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		if n := ansiLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// ansiLen returns the length of the SGR escape sequence s starts with, or 0.
+func ansiLen(s string) int {
+	if !strings.HasPrefix(s, "\x1b[") {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == 'm':
+			return i + 1
+		case c != ';' && (c < '0' || c > '9'):
+			return 0
+		}
+	}
+	return 0
+}
+
+// openNoteAt selects the note at path in the list and starts decrypting it,
+// clearing the list filter if it hides the note.
+func (m *model) openNoteAt(path string) tea.Cmd {
+	index := noteIndex(m.list.Items(), path)
+	if index == -1 {
+		m.fail("open note", enotes.NoteName(path), fmt.Errorf("%s is not in the notes list", path))
+		return nil
+	}
+	visibleIndex := noteIndex(m.list.VisibleItems(), path)
+	if visibleIndex == -1 {
+		m.list.ResetFilter()
+		visibleIndex = index
+	}
+
+	m.list.Select(visibleIndex)
+	m.toNote(index)
+	m.notePath = path
+	m.loadingNote = true
+	m.noteLinks = nil
+	m.backlinks = nil
+	m.linkIndex = -1
+	m.noteSearchQuery = ""
+	m.rawView = false
+	m.noteHeadings = nil
+	m.foldedHeadings = nil
+	m.outlineIndex = 0
+	m.noteSecrets = nil
+	m.revealedSecrets = nil
+	m.showingSecrets = false
+	m.noteTOTPs = nil
+	m.noteTOTPErrs = nil
+	m.resetRenderedNote()
+	m.noteViewport.GotoTop()
+	return tea.Batch(openNote(path, m.password), getBacklinks(path, m.password))
+}
+
+func noteIndex(items []list.Item, path string) int {
+	for i, it := range items {
+		if it, ok := it.(fileItem); ok && it.path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *model) cycleLinks(step int) {
+	total := len(m.noteLinks) + len(m.backlinks)
+	if total == 0 {
+		return
+	}
+	if m.linkIndex == -1 && step < 0 {
+		m.linkIndex = 0
+	}
+	// Links in folded sections are skipped.
+	for i := 0; i < total; i++ {
+		m.linkIndex = (m.linkIndex + step + total) % total
+		if m.linkIndex >= len(m.noteLinks) || !m.folded(m.noteLinks[m.linkIndex].Start) {
+			break
+		}
+	}
+	m.scrollToLink = true
+}
+
+// followLink opens the note the selected link or backlink points to, creating
+// it if it doesn't exist yet.
+func (m *model) followLink() tea.Cmd {
+	if m.linkIndex < 0 {
+		return nil
+	}
+	if i := m.linkIndex - len(m.noteLinks); i >= 0 {
+		if i >= len(m.backlinks) {
+			return nil
+		}
+		return m.visitNote(enotes.NotePath(m.backlinks[i]))
+	}
+	link := m.noteLinks[m.linkIndex]
+	if link.Name == "" {
+		m.fail("follow link", m.noteName(), fmt.Errorf("%q points outside the notes directory", link.Target))
+		return nil
+	}
+
+	if !link.Exists {
+		m.resetChosen()
+		m.toNewNote()
+		m.newNoteName = link.Name
+		m.editorActive = true
+		return createNote(link.Name, m.password)
+	}
+
+	return m.visitNote(enotes.NotePath(link.Name))
+}
+
+// visitNote opens the note at path, remembering the current one to go back to.
+func (m *model) visitNote(path string) tea.Cmd {
+	m.noteBack = append(m.noteBack, m.notePath)
+	m.noteForward = nil
+	return m.openNoteAt(path)
+}
+
+// navigateHistory opens the previous note (back) or the next one (forward) in
+// the notes opened by following links.
+func (m *model) navigateHistory(back bool) tea.Cmd {
+	pop, push := &m.noteBack, &m.noteForward
+	if !back {
+		pop, push = push, pop
+	}
+	if len(*pop) == 0 {
+		return nil
+	}
+	path := (*pop)[len(*pop)-1]
+	*pop = (*pop)[:len(*pop)-1]
+	*push = append(*push, m.notePath)
+	return m.openNoteAt(path)
+}
+
+// linkedBody returns the note body from start to end with its wiki links
+// replaced by Markdown that highlights them: bold for links to existing notes,
+// struck through for broken ones and inline code for the selected one.
+func (m model) linkedBody(start, end int) string {
+	var b strings.Builder
+	last := start
+	for i, link := range m.noteLinks {
+		if link.Start < start || link.End > end {
+			continue
+		}
+		b.WriteString(m.noteBody[last:link.Start])
+		switch {
+		case i == m.linkIndex:
+			b.WriteString("`" + strings.ReplaceAll(link.Label, "`", "'") + "`")
+		case link.Exists:
+			b.WriteString("**" + escapeMarkdown(link.Label) + "**")
+		default:
+			b.WriteString("~~" + escapeMarkdown(link.Label) + "~~")
+		}
+		last = link.End
+	}
+	b.WriteString(m.noteBody[last:end])
+	return b.String()
+}
+
+var markdownSpecialChars = regexp.MustCompile("[\\\\`*_~\\[\\]<>#|]")
+
+func escapeMarkdown(s string) string {
+	return markdownSpecialChars.ReplaceAllString(s, "\\$0")
+}
+
+// backlinksView shows the notes that link to the current one, with the
+// selected one highlighted.
+func (m model) backlinksView() string {
+	if len(m.backlinks) == 0 {
+		return ""
+	}
+	names := make([]string, len(m.backlinks))
+	for i, name := range m.backlinks {
+		if i == m.linkIndex-len(m.noteLinks) {
+			names[i] = selectedLinkStyle.Render(name)
+		} else {
+			names[i] = bold.Render(name)
+		}
+	}
+	return lipgloss.NewStyle().Width(m.width).Render(descStyle.Render("Linked from ") + strings.Join(names, dot))
+}
+
+// scrollToSelectedLink scrolls the note so the selected link is visible, given
+// the rendered note. The line of the link is estimated from its position in
+// the note body and then adjusted to the closest rendered line containing it.
+func (m *model) scrollToSelectedLink(rendered string) {
+	if m.linkIndex < 0 || m.linkIndex >= len(m.noteLinks) {
+		// Backlinks are always visible.
+		return
+	}
+	link := m.noteLinks[m.linkIndex]
+	lines := strings.Split(stripANSI(rendered), "\n")
+	bodyLines := strings.Count(m.noteBody, "\n") + 1
+	estimate := strings.Count(m.noteBody[:link.Start], "\n") * len(lines) / bodyLines
+
+	line := estimate
+	best := -1
+	for i, l := range lines {
+		if !strings.Contains(l, link.Label) {
+			continue
+		}
+		if best == -1 || abs(i-estimate) < abs(best-estimate) {
+			best = i
+		}
+	}
+	if best != -1 {
+		line = best
+	}
+
+	top := m.noteViewport.YOffset
+	if line < top || line >= top+m.noteViewport.Height {
+		m.noteViewport.SetYOffset(line - m.noteViewport.Height/2)
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
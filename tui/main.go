@@ -1,17 +1,20 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/zd4y/enotes/config"
 	"github.com/zd4y/enotes/enotes"
 )
 
@@ -25,33 +28,54 @@ func (i item) Title() string       { return i.title }
 func (i item) Description() string { return i.desc }
 func (i item) FilterValue() string { return i.title }
 
+var newNoteItem = item{title: "New note", desc: "Write a new encrypted note"}
+
 type fileItem struct {
+	path string
 	file fs.FileInfo
+	// pinned, sortedBy and created are set when the list is sorted.
+	pinned   bool
+	sortedBy string
+	created  time.Time
 }
 
 func (i fileItem) Title() string {
-	return enotes.NoteName(i.file.Name())
+	if i.pinned {
+		return "★ " + enotes.NoteName(i.path)
+	}
+	return enotes.NoteName(i.path)
 }
 
 func (i fileItem) Description() string {
+	switch i.sortedBy {
+	case "created":
+		return "Created: " + i.created.Format(time.Stamp)
+	case "size":
+		return "Size: " + enotes.ByteCount(i.file.Size())
+	}
 	return "Modified: " + i.file.ModTime().Format(time.Stamp)
 }
 
 func (i fileItem) FilterValue() string {
-	return i.file.Name()
+	return i.path
 }
 
 type model struct {
 	quitting            bool
 	width               int
+	height              int
+	windowWidth         int
 	list                list.Model
 	chosen              int
+	notePath            string
 	editorActive        bool
 	newNoteName         string
 	noteAlreadyExists   bool
 	password            string
 	passwordVerified    bool
 	noteContents        string
+	noteMeta            enotes.Metadata
+	noteBody            string
 	noteViewport        viewport.Model
 	spinner             spinner.Model
 	loadingNote         bool
@@ -60,13 +84,90 @@ type model struct {
 	creatingNewPassword bool
 	newPasswordFocus    int
 	pwConfirmTextInput  textinput.Model
-	err                 error
+	err                 *opError
+	errLog              []*opError
+	showingErrLog       bool
+	checking            bool
+	checkReport         *enotes.CheckReport
+	exporting           bool
+	exportRunning       bool
+	exportResult        string
+	sharing             bool
+	shareFocus          int
+	shareRecipientInput textinput.Model
+	sharePathInput      textinput.Model
+	shareResult         string
+	receiving           bool
+	receiveFocus        int
+	receiveArmored      []byte
+	receiveKeyInput     textinput.Model
+	receiveNameInput    textinput.Model
+	config              *config.Config
+	showingAttachments  bool
+	attachments         []enotes.Attachment
+	attachmentIndex     int
+	attachmentPrompt    int
+	attachmentStatus    string
+	viewerActive        bool
+	tempCleanups        []func() error
+	noteLinks           []enotes.WikiLink
+	linkIndex           int
+	scrollToLink        bool
+	noteBack            []string
+	noteForward         []string
+	backlinks           []string
+	searching           bool
+	searchInput         textinput.Model
+	searchMode          int
+	searchResults       []enotes.SearchResult
+	grepResults         []enotes.GrepResult
+	searchErr           string
+	searchIndex         int
+	noteSearching       bool
+	noteSearchInput     textinput.Model
+	noteSearchQuery     string
+	noteMatches         []noteMatch
+	noteMatchIndex      int
+	scrollToMatch       bool
+	showingOutline      bool
+	noteHeadings        []enotes.Heading
+	headingLines        []int
+	outlineIndex        int
+	foldedHeadings      map[int]bool
+	scrollToHeading     bool
+	renderCache         *renderCache
+	noteVersion         int
+	renderKey           renderKey
+	renderedKey         renderKey
+	renderedNote        string
+	renderings          int
+	noteContentKey      noteContentKey
+	rawView             bool
+	showingPreview      bool
+	previews            map[previewKey]notePreview
+	previewKey          previewKey
+	previewSeq          int
+	clipboardStatus     string
+	pickingSnippet      bool
+	snippets            []enotes.Snippet
+	snippetIndex        int
+	noteSecrets         []enotes.SecretBlock
+	revealedSecrets     map[int]bool
+	showingSecrets      bool
+	secretIndex         int
+	noteTOTPs           []enotes.TOTP
+	noteTOTPErrs        []error
+	totpSeq             int
+	keys                keyMap
+	theme               *theme
+	showingHelp         bool
+	notes               []fileItem
+	pinned              map[string]bool
+	created             map[string]time.Time
 }
 
 func initialModel() model {
-	items := []list.Item{
-		item{title: "New note", desc: "Write a new encrypted note"},
-	}
+	items := []list.Item{newNoteItem}
 
 	textInput := textinput.New()
 	textInput.Placeholder = "Password"
@@ -81,6 +182,22 @@ func initialModel() model {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	keys, err := newKeyMap(cfg.Keys)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	theme, err := loadTheme(cfg)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	pwConfirmTextInput := textinput.New()
 	pwConfirmTextInput.Placeholder = "Confirm Password"
 	pwConfirmTextInput.EchoMode = textinput.EchoPassword
@@ -93,8 +210,33 @@ func initialModel() model {
 		spinner:            s,
 		passwordExists:     passwordExists,
 		pwConfirmTextInput: pwConfirmTextInput,
+		config:             cfg,
+		renderCache:        newRenderCache(),
+		previews:           map[previewKey]notePreview{},
+		keys:               keys,
+		theme:              theme,
 	}
+	theme.apply(&m.list)
 	m.list.Title = "Notes"
+	m.list.KeyMap.CursorUp = keys.Up
+	m.list.KeyMap.CursorDown = keys.Down
+	m.list.KeyMap.Filter = withDesc(keys.Find, "filter")
+	m.list.KeyMap.Quit = withDesc(keys.Back, "quit")
+	m.list.KeyMap.ForceQuit = keys.Quit
+	m.list.KeyMap.ShowFullHelp = withDesc(keys.Help, "more")
+	m.list.KeyMap.CloseFullHelp = withDesc(keys.Help, "close help")
+	m.list.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			keys.Check, keys.ExportHTML, keys.Import, keys.ErrorLog, keys.Search, keys.Preview,
+			keys.Sort, keys.ReverseSort, keys.Pin,
+		}
+	}
+	m.noteViewport.KeyMap.Up = keys.Up
+	m.noteViewport.KeyMap.Down = keys.Down
+	m.noteViewport.KeyMap.PageUp = keys.PageUp
+	m.noteViewport.KeyMap.PageDown = keys.PageDown
+	m.noteViewport.KeyMap.HalfPageUp = keys.HalfPageUp
+	m.noteViewport.KeyMap.HalfPageDown = keys.HalfPageDown
 	return m
 }
 
@@ -118,8 +260,25 @@ func (m model) inNewNoteEditor() bool {
 	return len(m.newNoteName) > 0
 }
 
+func (m model) selectedNote() (fileItem, bool) {
+	item, ok := m.list.SelectedItem().(fileItem)
+	return item, ok
+}
+
+// noteName returns the name of the open note, which is kept apart from the
+// list selection because filtering the list can move it to another note.
+func (m model) noteName() string {
+	if m.notePath == "" {
+		return ""
+	}
+	return enotes.NoteName(m.notePath)
+}
+
 func (m *model) resetChosen() {
 	m.chosen = -1
+	m.notePath = ""
+	m.noteBack = nil
+	m.noteForward = nil
 }
 
 func (m *model) toNewNote() {
@@ -135,20 +294,35 @@ func (m model) Init() tea.Cmd {
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	if m.quitting || m.err != nil {
+	if m.quitting {
 		return m, tea.Quit
 	}
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if msg.String() == "ctrl+c" {
+		if key.Matches(msg, m.keys.Quit) {
 			m.quitting = true
 			return m, nil
 		}
 	case editorFinishedMsg:
 		m.editorActive = false
+		if errors.Is(msg.err, enotes.IndexError) {
+			name := m.newNoteName
+			if !m.inNewNoteEditor() {
+				name = m.noteName()
+			}
+			m.logError("save note", name, msg.err)
+			msg.err = nil
+		}
 		if msg.err != nil {
-			m.err = msg.err
+			if m.inNewNoteEditor() {
+				name := m.newNoteName
+				m.newNoteName = ""
+				m.resetChosen()
+				m.fail("create note", name, msg.err)
+				return m, nil
+			}
+			m.fail("edit note", m.noteName(), msg.err)
 			return m, nil
 		}
 		m.loadingNote = true
@@ -157,30 +331,23 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.resetChosen()
 			return m, getDirFiles
 		}
-		item := m.list.SelectedItem().(fileItem)
-		return m, openNote(item.file.Name(), m.password)
+		return m, openNote(m.notePath, m.password)
 	case dirFilesMsg:
-		itemsLen := len(m.list.Items())
-		cmds := make([]tea.Cmd, 0, len(msg.files))
-		skipped := 0
-		for i, file := range msg.files {
-			if !enotes.IsNote(file.Name()) {
-				skipped += 1
-				continue
-			}
-			var cmd tea.Cmd
-			index := i + 1 - skipped
-			if itemsLen > index {
-				cmd = m.list.SetItem(index, fileItem{file})
-			} else {
-				cmd = m.list.InsertItem(index, fileItem{file})
-			}
-			cmds = append(cmds, cmd)
+		if msg.err != nil {
+			m.fail("read notes directory", "", msg.err)
+			return m, nil
 		}
-		return m, tea.Batch(cmds...)
+		m.notes = msg.notes
+		cmd := m.sortNotes()
+		if m.passwordVerified && m.config.Sort == "created" {
+			// New notes get their creation time when they are indexed.
+			return m, tea.Batch(cmd, loadCreatedTimes(m.password))
+		}
+		return m, cmd
 	case newPasswordMsg:
 		if msg.err != nil {
-			m.err = msg.err
+			m.resetNewPassword()
+			m.fail("create password", "", msg.err)
 			return m, nil
 		}
 		m.password = ""
@@ -190,33 +357,83 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, textinput.Blink
 	case verifyPasswordMsg:
 		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
-		}
-		if !msg.passwordsMatch {
-			m.err = enotes.IncorrectPasswordError
+			m.password = ""
+			m.textInput.SetValue("")
+			m.fail("verify password", "", msg.err)
 			return m, nil
 		}
 		m.passwordVerified = true
+		cmds := []tea.Cmd{loadSearchIndex(m.password), loadPinnedNotes(m.password)}
+		if m.config.Sort == "created" {
+			cmds = append(cmds, loadCreatedTimes(m.password))
+		}
+		return m, tea.Batch(cmds...)
+	case searchIndexMsg:
+		if msg.err != nil {
+			m.fail("load search index", "", msg.err)
+		}
 		return m, nil
 	case tea.WindowSizeMsg:
 		headerHeight := lipgloss.Height(m.noteHeaderView())
-		footerHeight := lipgloss.Height(m.noteFooterView())
-		verticalMarginHeight := headerHeight + footerHeight
 
 		m.width = min(msg.Width, 100)
-		m.noteViewport.Width = m.width
-		m.noteViewport.Height = msg.Height - verticalMarginHeight
+		m.height = msg.Height
+		m.windowWidth = msg.Width
+		m.noteViewport.Width = m.noteWidth()
+		m.resizeNoteViewport()
 		m.noteViewport.YPosition = headerHeight
-
-		h, v := docStyle.GetFrameSize()
-		m.list.SetSize(msg.Width-h, msg.Height-v)
+		m.resizeList()
+	case renderNoteMsg:
+		// Renderings finish after leaving the note too, like when attaching
+		// files, so they are kept here for when it is shown again.
+		if msg.err != nil {
+			if msg.key == m.renderKey {
+				name := m.noteName()
+				m.resetChosen()
+				m.fail("render note", name, msg.err)
+			}
+			return m, nil
+		}
+		m.renderCache.add(msg.key, msg.out)
+		if msg.key == m.renderKey {
+			m.setRenderedNote(msg.out)
+		}
+	case createdTimesMsg:
+		if msg.err != nil {
+			m.fail("load creation times", "", msg.err)
+			return m, nil
+		}
+		m.created = msg.created
+		return m, m.sortNotes()
+	case pinMsg:
+		if msg.err != nil {
+			op := "pin note"
+			if msg.name == "" {
+				op = "load pinned notes"
+			}
+			m.fail(op, msg.name, msg.err)
+			return m, nil
+		}
+		m.setPinned(msg.pinned)
+		return m, m.sortNotes()
+	case previewTickMsg, previewMsg:
+		return previewUpdate(msg, m)
+	case clipboardMsg, clearClipboardMsg, clipboardClearedMsg:
+		return clipboardUpdate(msg, m)
+	case totpTickMsg:
+		return totpUpdate(msg, m)
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 	}
 
+	if m.err != nil {
+		return errorUpdate(msg, m)
+	}
+	if m.showingErrLog {
+		return errorLogUpdate(msg, m)
+	}
 	if m.inNewPassword() {
 		return newPasswordUpdate(msg, m)
 	}
@@ -226,9 +443,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if !m.passwordVerified {
 		return m, nil
 	}
+	if m.checking {
+		return checkUpdate(msg, m)
+	}
+	if m.exporting {
+		return exportUpdate(msg, m)
+	}
+	if m.receiving {
+		return receiveUpdate(msg, m)
+	}
+	if m.sharing {
+		return shareUpdate(msg, m)
+	}
+	if m.showingAttachments {
+		return attachmentsUpdate(msg, m)
+	}
 	if m.inNote() {
 		return noteUpdate(msg, m)
 	}
+	if m.searching {
+		return searchUpdate(msg, m)
+	}
 	if m.inNewNoteEditor() {
 		return newNoteEditorUpdate(msg, m)
 	}
@@ -244,7 +479,11 @@ func (m model) View() string {
 	}
 
 	if m.err != nil {
-		return m.err.Error() + "\n"
+		return errorView(m)
+	}
+
+	if m.showingErrLog {
+		return errorLogView(m)
 	}
 
 	if m.inNewPassword() {
@@ -259,9 +498,32 @@ func (m model) View() string {
 		return fmt.Sprintf("%s Verifying password\n", m.spinner.View())
 	}
 
+	if m.checking {
+		return checkView(m)
+	}
+
+	if m.exporting {
+		return exportView(m)
+	}
+
+	if m.receiving {
+		return receiveView(m)
+	}
+
+	if m.sharing {
+		return shareView(m)
+	}
+
+	if m.showingAttachments {
+		return attachmentsView(m)
+	}
+
 	if m.inNote() {
 		return noteView(m)
 	}
+	if m.searching {
+		return searchView(m)
+	}
 	if m.inNewNoteEditor() {
 		return newNoteEditorView(m)
 	}
@@ -281,8 +543,25 @@ func min(a, b int) int {
 func Run() {
 	p := tea.NewProgram(initialModel(), tea.WithAltScreen(), tea.WithMouseCellMotion())
 
-	if err := p.Start(); err != nil {
+	final, err := p.StartReturningModel()
+	if m, ok := final.(model); ok {
+		for _, cleanup := range m.tempCleanups {
+			cleanup()
+		}
+	}
+	// The program has exited, so the terminal can be written to directly.
+	if err := clearClipboard(-1, os.Stdout); err != nil {
+		fmt.Println("Error clearing clipboard:", err)
+	}
+	if err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
 }
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
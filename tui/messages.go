@@ -1,16 +1,17 @@
 package tui
 
 import (
+	"bytes"
 	"fmt"
-	"io/fs"
-	"io/ioutil"
 	"os"
 	"os/exec"
+	"runtime"
 	"strings"
 
 	"github.com/zd4y/enotes/enotes"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
 )
 
 type editorFinishedMsg struct {
@@ -29,7 +30,10 @@ func openEditor(path string, callback func(error) error) tea.Cmd {
 	c := exec.Command(editor, args...)
 	return tea.ExecProcess(c, func(err error) tea.Msg {
 		if err != nil {
-			return editorFinishedMsg{err: err}
+			// The temporary file holds the plaintext note, don't leave it
+			// behind when the editor fails.
+			os.Remove(path)
+			return editorFinishedMsg{err: fmt.Errorf("editor: %w", err)}
 		}
 		err = callback(err)
 		return editorFinishedMsg{path, err}
@@ -78,36 +82,295 @@ func newPassword(password string) tea.Cmd {
 }
 
 type verifyPasswordMsg struct {
-	passwordsMatch bool
-	err            error
+	err error
 }
 
 func verifyPassword(password string) tea.Cmd {
 	return func() tea.Msg {
 		err := enotes.VerifyPassword(password)
-		return verifyPasswordMsg{err == nil, err}
+		return verifyPasswordMsg{err}
 	}
 }
 
 type openNoteMsg struct {
-	note string
-	err  error
+	note  string
+	links []enotes.WikiLink
+	err   error
 }
 
 func openNote(notePath string, password string) tea.Cmd {
 	return func() tea.Msg {
 		note, err := enotes.OpenNote(notePath, password)
-		return openNoteMsg{note, err}
+		if err != nil {
+			return openNoteMsg{err: err}
+		}
+		_, body := enotes.ParseNote(note)
+		links := enotes.WikiLinks(enotes.NoteName(notePath), body)
+		return openNoteMsg{note, links, nil}
+	}
+}
+
+type renderNoteMsg struct {
+	key renderKey
+	out string
+	err error
+}
+
+func renderNote(key renderKey, markdown string, t *theme) tea.Cmd {
+	return func() tea.Msg {
+		if key.raw {
+			out, err := rawNote(markdown, key.width, t.syntax)
+			return renderNoteMsg{key, out, err}
+		}
+		r, err := glamour.NewTermRenderer(glamour.WithStyles(t.markdown), glamour.WithWordWrap(key.width))
+		if err != nil {
+			return renderNoteMsg{key: key, err: err}
+		}
+		out, err := r.Render(markdown)
+		return renderNoteMsg{key, out, err}
+	}
+}
+
+type previewTickMsg struct {
+	seq int
+}
+
+type previewMsg struct {
+	key     previewKey
+	preview notePreview
+}
+
+// previewNote renders the note identified by key for the preview, decrypting it
+// unless the cached preview has its body.
+func previewNote(key previewKey, cached notePreview, width int, password string, t *theme) tea.Cmd {
+	return func() tea.Msg {
+		body := cached.body
+		if !cached.decrypted {
+			note, err := enotes.OpenNote(key.path, password)
+			if err != nil {
+				return previewMsg{key, notePreview{err: err}}
+			}
+			_, body = enotes.ParseNote(note)
+			body = previewBody(body)
+		}
+		preview := notePreview{body: body, decrypted: true, width: width}
+		r, err := glamour.NewTermRenderer(glamour.WithStyles(t.markdown), glamour.WithWordWrap(width))
+		if err != nil {
+			preview.err = err
+			return previewMsg{key, preview}
+		}
+		preview.rendered, preview.err = r.Render(maskSecrets(body))
+		return previewMsg{key, preview}
+	}
+}
+
+type backlinksMsg struct {
+	path      string
+	backlinks []string
+	err       error
+}
+
+func getBacklinks(notePath string, password string) tea.Cmd {
+	return func() tea.Msg {
+		backlinks, err := enotes.Backlinks(enotes.NoteName(notePath), password)
+		return backlinksMsg{notePath, backlinks, err}
+	}
+}
+
+type searchIndexMsg struct {
+	err error
+}
+
+func loadSearchIndex(password string) tea.Cmd {
+	return func() tea.Msg {
+		return searchIndexMsg{enotes.LoadSearchIndex(password)}
+	}
+}
+
+type searchMsg struct {
+	query       string
+	mode        int
+	results     []enotes.SearchResult
+	grepResults []enotes.GrepResult
+	err         error
+}
+
+// searchNotes searches the notes with the search index, or greps them if
+// grepMode is not empty.
+func searchNotes(query string, mode int, grepMode enotes.GrepMode, password string) tea.Cmd {
+	return func() tea.Msg {
+		if grepMode != "" {
+			results, err := enotes.Grep(query, grepMode, password)
+			return searchMsg{query: query, mode: mode, grepResults: results, err: err}
+		}
+		results, err := enotes.Search(query, password)
+		return searchMsg{query: query, mode: mode, results: results, err: err}
 	}
 }
 
-type dirFilesMsg struct{ files []fs.FileInfo }
+type checkMsg struct {
+	report *enotes.CheckReport
+	err    error
+}
+
+func checkNotes(password string) tea.Cmd {
+	return func() tea.Msg {
+		report, err := enotes.Check(password)
+		return checkMsg{report, err}
+	}
+}
+
+type exportMsg struct {
+	dir string
+	n   int
+	err error
+}
+
+func exportNotes(dir string, password string, format enotes.ExportFormat) tea.Cmd {
+	return func() tea.Msg {
+		n, err := enotes.Export(dir, password, format)
+		return exportMsg{dir, n, err}
+	}
+}
+
+type shareMsg struct {
+	armored string
+	path    string
+	err     error
+}
+
+func shareNote(notePath string, password string, recipient string, outPath string) tea.Cmd {
+	return func() tea.Msg {
+		out := &bytes.Buffer{}
+		if err := enotes.ShareNote(notePath, password, recipient, out); err != nil {
+			return shareMsg{err: err}
+		}
+		if outPath == "" {
+			return shareMsg{armored: out.String()}
+		}
+		file, err := os.OpenFile(outPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return shareMsg{err: err}
+		}
+		defer file.Close()
+		if _, err := file.Write(out.Bytes()); err != nil {
+			return shareMsg{err: err}
+		}
+		return shareMsg{path: outPath, err: file.Close()}
+	}
+}
+
+type receiveMsg struct {
+	name string
+	err  error
+}
+
+func receiveNote(armored string, identity string, name string, password string) tea.Cmd {
+	return func() tea.Msg {
+		err := enotes.ImportShared(armored, identity, name, password)
+		return receiveMsg{name, err}
+	}
+}
+
+type attachmentsMsg struct {
+	attachments []enotes.Attachment
+	err         error
+}
+
+func listAttachments(notePath string) tea.Cmd {
+	return func() tea.Msg {
+		attachments, err := enotes.ListAttachments(notePath)
+		return attachmentsMsg{attachments, err}
+	}
+}
+
+type attachFileMsg struct {
+	name string
+	err  error
+}
+
+func attachFile(notePath string, srcPath string, password string) tea.Cmd {
+	return func() tea.Msg {
+		name, err := enotes.AttachFile(notePath, srcPath, password)
+		return attachFileMsg{name, err}
+	}
+}
+
+type extractAttachmentMsg struct {
+	path string
+	err  error
+}
+
+func extractAttachment(notePath string, name string, dstPath string, password string) tea.Cmd {
+	return func() tea.Msg {
+		err := enotes.ExtractAttachment(notePath, name, dstPath, password)
+		return extractAttachmentMsg{dstPath, err}
+	}
+}
+
+type viewerFinishedMsg struct {
+	// cleanup removes the decrypted copy of the attachment, when it can't be
+	// removed as soon as the viewer exits.
+	cleanup func() error
+	err     error
+}
+
+func openAttachment(notePath string, name string, password string, viewer string) tea.Cmd {
+	return func() tea.Msg {
+		tempPath, cleanup, err := enotes.OpenAttachment(notePath, name, password)
+		if err != nil {
+			return viewerFinishedMsg{err: err}
+		}
+
+		// Openers like xdg-open return before the file is read, so the copy is
+		// kept until enotes exits.
+		waits := viewer != ""
+		if !waits {
+			viewer = defaultViewer()
+		}
+		viewerCmd := strings.Split(viewer, " ")
+		args := append(viewerCmd[1:], tempPath)
+		c := exec.Command(viewerCmd[0], args...)
+		return tea.ExecProcess(c, func(err error) tea.Msg {
+			if waits {
+				if cleanupErr := cleanup(); err == nil {
+					err = cleanupErr
+				}
+				return viewerFinishedMsg{err: err}
+			}
+			return viewerFinishedMsg{cleanup: cleanup, err: err}
+		})()
+	}
+}
+
+func defaultViewer() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "open"
+	case "windows":
+		return "explorer"
+	default:
+		return "xdg-open"
+	}
+}
+
+type dirFilesMsg struct {
+	notes []fileItem
+	err   error
+}
 
 func getDirFiles() tea.Msg {
-	files, err := ioutil.ReadDir("./")
+	paths, err := enotes.ListNotes()
 	if err != nil {
-		fmt.Println("fatal: ", err)
-		os.Exit(1)
+		return dirFilesMsg{err: err}
+	}
+	notes := make([]fileItem, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return dirFilesMsg{err: err}
+		}
+		notes = append(notes, fileItem{path: path, file: info})
 	}
-	return dirFilesMsg{files}
+	return dirFilesMsg{notes: notes}
 }
@@ -4,19 +4,19 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/zd4y/enotes/enotes"
 )
 
 func newNoteUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 	if msg, ok := msg.(tea.KeyMsg); ok {
-		msg := msg.String()
-		switch msg {
-		case "esc":
+		switch {
+		case key.Matches(msg, m.keys.Cancel):
 			m.noteAlreadyExists = false
 			m.resetChosen()
 			return m, nil
-		case "enter":
+		case key.Matches(msg, m.keys.Confirm):
 			m.noteAlreadyExists = false
 			newNoteName := m.textInput.Value()
 			if newNoteName == "" {
@@ -26,7 +26,7 @@ func newNoteUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 				m.noteAlreadyExists = true
 				return m, nil
 			} else if err != nil {
-				m.err = err
+				m.fail("check note", newNoteName, err)
 				return m, nil
 			}
 			m.newNoteName = newNoteName
@@ -49,5 +49,5 @@ func newNoteView(m model) string {
 		s += "\nNote already exists"
 	}
 
-	return s + "\n(esc to quit)\n"
+	return s + fmt.Sprintf("\n(%s to quit)\n", m.keys.Cancel.Help().Key)
 }
@@ -4,18 +4,18 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func newPasswordUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 	if msg, ok := msg.(tea.KeyMsg); ok {
-		msg := msg.String()
-		switch msg {
-		case "esc":
+		switch {
+		case key.Matches(msg, m.keys.Cancel):
 			m.quitting = true
 			return m, nil
-		case "enter":
+		case key.Matches(msg, m.keys.Confirm):
 			switch m.newPasswordFocus {
 			case 0:
 				m.password = m.textInput.Value()
@@ -31,8 +31,9 @@ func newPasswordUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 					m.creatingNewPassword = true
 					return m, tea.Batch(newPassword(m.password), cmd)
 				} else {
-					m.err = errors.New("Password and confirm password didn't match")
-					return m, nil
+					m.resetNewPassword()
+					m.fail("create password", "", errors.New("password and confirm password didn't match"))
+					return m, m.textInput.Focus()
 				}
 			}
 		}
@@ -50,6 +51,16 @@ func newPasswordUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m *model) resetNewPassword() {
+	m.password = ""
+	m.creatingNewPassword = false
+	m.newPasswordFocus = 0
+	m.textInput.SetValue("")
+	m.pwConfirmTextInput.SetValue("")
+	m.pwConfirmTextInput.Blur()
+	m.textInput.Focus()
+}
+
 func newPasswordView(m model) string {
 	if m.creatingNewPassword {
 		return fmt.Sprintf("%s Creating password\n", m.spinner.View())
@@ -59,6 +70,6 @@ func newPasswordView(m model) string {
 		"New Password: %s\n\nConfirm password: %s\n\n%s\n",
 		m.textInput.View(),
 		m.pwConfirmTextInput.View(),
-		"(esc to quit)",
+		fmt.Sprintf("(%s to quit)", m.keys.Cancel.Help().Key),
 	)
 }
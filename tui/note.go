@@ -4,63 +4,169 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/zd4y/enotes/enotes"
 )
 
-var (
-	bold       = lipgloss.NewStyle().Bold(true)
-	titleStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color("62")).
-			Foreground(lipgloss.Color("230")).
-			Padding(0, 1)
-	keyStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
-	descStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#4A4A4A"))
-	sepStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#3C3C3C"))
-	dot       = sepStyle.Render(" • ")
-)
-
 func noteUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	var totpCmd tea.Cmd
 	switch msg := msg.(type) {
 	case openNoteMsg:
 		m.loadingNote = false
 		if msg.err != nil {
-			m.err = msg.err
+			name := m.noteName()
+			m.resetChosen()
+			m.fail("decrypt note", name, msg.err)
 			return m, nil
 		}
 
 		m.noteContents = msg.note
-		newHeaderHeight := lipgloss.Height(m.noteHeaderView())
-		m.noteViewport.Height -= newHeaderHeight - 1
+		m.noteVersion = m.renderCache.version(msg.note)
+		m.noteMeta, m.noteBody = enotes.ParseNote(msg.note)
+		m.noteLinks = msg.links
+		m.linkIndex = -1
+		m.noteHeadings = enotes.Headings(m.noteBody)
+		m.noteSecrets = enotes.SecretBlocks(m.noteBody)
+		m.noteTOTPs, m.noteTOTPErrs = enotes.NoteTOTPs(m.noteBody)
+		totpCmd = m.startTOTP()
+		if m.outlineIndex >= len(m.noteHeadings) {
+			m.outlineIndex = max(len(m.noteHeadings)-1, 0)
+		}
+		for i := range m.foldedHeadings {
+			if i >= len(m.noteHeadings) {
+				delete(m.foldedHeadings, i)
+			}
+		}
+		m.resizeNoteViewport()
+	case backlinksMsg:
+		if msg.path != m.notePath {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.fail("find backlinks", m.noteName(), msg.err)
+			return m, nil
+		}
+		m.backlinks = msg.backlinks
+		m.resizeNoteViewport()
 	case tea.KeyMsg:
-		switch msg := msg.String(); msg {
-		case "esc", "q":
+		m.clipboardStatus = ""
+		if m.noteSearching {
+			return noteSearchUpdate(msg, m)
+		}
+		if m.showingHelp {
+			if key.Matches(msg, m.keys.Help, m.keys.Back) {
+				m.showingHelp = false
+			}
+			return m, nil
+		}
+		if m.pickingSnippet {
+			return snippetsUpdate(msg, m)
+		}
+		if m.showingSecrets {
+			return secretsUpdate(msg, m)
+		}
+		if m.showingOutline {
+			var handled bool
+			if m, handled = outlineUpdate(msg, m); handled {
+				return noteUpdate(nil, m)
+			}
+		}
+		switch {
+		case key.Matches(msg, m.keys.Help):
+			m.showingHelp = true
+			return m, nil
+		case key.Matches(msg, m.keys.Back):
+			if key.Matches(msg, m.keys.Cancel) && m.noteSearchQuery != "" {
+				m.noteSearchQuery = ""
+				break
+			}
 			m.resetChosen()
 			return m, nil
-		case "e":
+		case key.Matches(msg, m.keys.Find):
+			if !m.loadingNote {
+				cmd := m.toNoteSearch()
+				m.resizeNoteViewport()
+				return m, cmd
+			}
+		case key.Matches(msg, m.keys.Copy):
+			if !m.loadingNote {
+				return m, copyToClipboard(m.noteContents, "note")
+			}
+		case key.Matches(msg, m.keys.CopyBlock):
+			if !m.loadingNote {
+				m.toSnippets()
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.Secrets):
+			if !m.loadingNote {
+				m.toSecrets()
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.GeneratePassword):
+			return m, m.generatePassword()
+		case key.Matches(msg, m.keys.Raw):
+			m.rawView = !m.rawView
+		case key.Matches(msg, m.keys.Outline):
+			m.toggleOutline()
+		case key.Matches(msg, m.keys.Fold):
+			if i := m.currentHeading(); i != -1 {
+				m.toggleFold(i)
+				m.outlineIndex = i
+				m.scrollToHeading = true
+			}
+		case key.Matches(msg, m.keys.FoldAll):
+			m.toggleFoldAll()
+		case key.Matches(msg, m.keys.NextMatch):
+			m.nextMatch(1)
+		case key.Matches(msg, m.keys.PrevMatch):
+			m.nextMatch(-1)
+		case key.Matches(msg, m.keys.NextLink):
+			m.cycleLinks(1)
+		case key.Matches(msg, m.keys.PrevLink):
+			m.cycleLinks(-1)
+		case key.Matches(msg, m.keys.FollowLink):
+			if !m.loadingNote {
+				cmd := m.followLink()
+				return m, cmd
+			}
+		case key.Matches(msg, m.keys.HistoryBack):
+			if !m.loadingNote {
+				cmd := m.navigateHistory(true)
+				return m, cmd
+			}
+		case key.Matches(msg, m.keys.HistoryForward):
+			if !m.loadingNote {
+				cmd := m.navigateHistory(false)
+				return m, cmd
+			}
+		case key.Matches(msg, m.keys.Attachments):
+			if !m.loadingNote {
+				cmd := m.toAttachments()
+				return m, cmd
+			}
+		case key.Matches(msg, m.keys.Share):
+			if !m.loadingNote {
+				cmd := m.toShare()
+				return m, cmd
+			}
+		case key.Matches(msg, m.keys.Edit):
 			if !m.loadingNote {
 				m.editorActive = true
-				item := m.list.SelectedItem().(fileItem)
-				return m, editNote(item.file.Name(), m.password)
+				return m, editNote(m.notePath, m.password)
 			}
 		}
 	}
 
-	r, err := glamour.NewTermRenderer(glamour.WithStandardStyle("dark"), glamour.WithWordWrap(m.width))
-	if err != nil {
-		m.err = err
-	}
-	out, err := r.Render(m.noteContents)
-	if err != nil {
-		m.err = err
+	if m.loadingNote {
+		return m, nil
 	}
-	m.noteViewport.SetContent(out)
+	renderCmd := m.refreshNote()
 
 	var cmd tea.Cmd
 	m.noteViewport, cmd = m.noteViewport.Update(msg)
-	return m, cmd
+	return m, tea.Batch(renderCmd, totpCmd, cmd)
 }
 
 func noteView(m model) string {
@@ -72,30 +178,131 @@ func noteView(m model) string {
 		return fmt.Sprintf("%s Loading editor\n", m.spinner.View())
 	}
 
-	if m.err != nil {
-		return "error loading note: " + m.err.Error() + "\n"
+	if m.renderedKey == (renderKey{}) {
+		return fmt.Sprintf("%s Rendering note\n", m.spinner.View())
+	}
+
+	note := m.noteViewport.View()
+	if m.pickingSnippet {
+		note = m.snippetsView()
+	}
+	if m.showingSecrets {
+		note = m.secretsView()
+	}
+	if m.showingHelp {
+		note = m.helpView()
+	}
+	if m.showingOutline {
+		note = lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.NewStyle().Width(m.noteWidth()).Render(note), m.outlineView())
+	}
+	parts := []string{m.noteHeaderView(), note}
+	for _, panel := range []string{m.totpView(), m.backlinksView()} {
+		if panel != "" {
+			parts = append(parts, panel)
+		}
 	}
+	return strings.Join(append(parts, m.noteFooterView()), "\n")
+}
 
-	return fmt.Sprintf("%s\n%s\n%s", m.noteHeaderView(), m.noteViewport.View(), m.noteFooterView())
+// resizeNoteViewport fits the note viewport in the space left by the header,
+// the one-time passwords, the backlinks and the footer.
+func (m *model) resizeNoteViewport() {
+	height := m.height - lipgloss.Height(m.noteHeaderView()) - lipgloss.Height(m.noteFooterView())
+	for _, panel := range []string{m.totpView(), m.backlinksView()} {
+		if panel != "" {
+			height -= lipgloss.Height(panel)
+		}
+	}
+	m.noteViewport.Height = max(height, 1)
 }
 
 func (m model) noteHeaderView() string {
-	item, ok := m.list.SelectedItem().(fileItem)
-	if !ok {
+	title := m.noteName()
+	if title == "" {
 		return ""
 	}
-	title := enotes.NoteName(item.file.Name())
-	return titleStyle.Render(title)
+	header := titleStyle.Render(title)
+	if len(m.noteMeta.Tags) > 0 {
+		header += " " + descStyle.Render("#"+strings.Join(m.noteMeta.Tags, " #"))
+	}
+	return header
 }
 
 func (m model) noteFooterView() string {
-	return strings.Join([]string{
-		help("↑/k", "up"),
-		help("↓/j", "down"),
-		help("e", "edit note"),
-		help("q/esc", "go back"),
-		help("ctrl+c", "quit"),
-	}, dot)
+	if m.noteSearching {
+		return m.noteSearchInput.View()
+	}
+	footer := lipgloss.NewStyle().Width(m.width)
+	if m.showingHelp {
+		return footer.Render(bindingsHelp("close help", m.keys.Help, m.keys.Back))
+	}
+	rawDesc := "raw"
+	if m.rawView {
+		rawDesc = "rendered"
+	}
+	if m.showingSecrets {
+		keys := []string{
+			bindingsHelp("up", m.keys.Up),
+			bindingsHelp("down", m.keys.Down),
+			bindingsHelp("reveal", m.keys.Reveal),
+			bindingsHelp("copy", m.keys.CopyValue),
+			bindingsHelp("close", m.keys.Back, m.keys.Secrets),
+		}
+		if m.clipboardStatus != "" {
+			keys = append([]string{bold.Render(m.clipboardStatus)}, keys...)
+		}
+		return footer.Render(joinHelp(keys...))
+	}
+	if m.pickingSnippet {
+		return footer.Render(joinHelp(
+			bindingsHelp("up", m.keys.Up),
+			bindingsHelp("down", m.keys.Down),
+			bindingsHelp("copy", m.keys.CopyValue),
+			bindingsHelp("cancel", m.keys.Back, m.keys.CopyBlock),
+		))
+	}
+	if m.showingOutline {
+		return footer.Render(joinHelp(
+			bindingsHelp("up", m.keys.Up),
+			bindingsHelp("down", m.keys.Down),
+			bindingsHelp("go to heading", m.keys.Confirm),
+			bindingsHelp("fold", m.keys.Toggle, m.keys.Fold),
+			bindingsHelp("fold all", m.keys.FoldAll),
+			bindingsHelp("close outline", m.keys.Outline, m.keys.Cancel),
+			bindingsHelp("quit", m.keys.Quit),
+		))
+	}
+	keys := []string{
+		bindingsHelp("up", m.keys.Up),
+		bindingsHelp("down", m.keys.Down),
+		bindingsHelp("search", m.keys.Find),
+	}
+	if m.clipboardStatus != "" {
+		keys = []string{bold.Render(m.clipboardStatus)}
+	} else if m.noteSearchQuery != "" {
+		keys = []string{
+			bold.Render(m.noteSearchStatus()),
+			bindingsHelp("next/previous", m.keys.NextMatch, m.keys.PrevMatch),
+			bindingsHelp("clear search", m.keys.Cancel),
+		}
+	}
+	return footer.Render(joinHelp(append(keys,
+		bindingsHelp("edit note", m.keys.Edit),
+		bindingsHelp(rawDesc, m.keys.Raw),
+		bindingsHelp("copy note", m.keys.Copy),
+		bindingsHelp("copy block/field", m.keys.CopyBlock),
+		bindingsHelp("secrets", m.keys.Secrets),
+		bindingsHelp("generate password", m.keys.GeneratePassword),
+		bindingsHelp("links", m.keys.NextLink),
+		bindingsHelp("outline", m.keys.Outline),
+		bindingsHelp("fold/all", m.keys.Fold, m.keys.FoldAll),
+		bindingsHelp("back/forward", m.keys.HistoryBack, m.keys.HistoryForward),
+		bindingsHelp("attachments", m.keys.Attachments),
+		bindingsHelp("share", m.keys.Share),
+		bindingsHelp("go back", m.keys.Back),
+		bindingsHelp("help", m.keys.Help),
+		bindingsHelp("quit", m.keys.Quit),
+	)...))
 }
 
 func help(key, desc string) string {
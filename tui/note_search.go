@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// Matches are shown in reverse video, which can be turned on and off
+	// without changing the colors glamour uses, and the current one is also
+	// underlined.
+	matchOn         = "\x1b[7m"
+	matchOff        = "\x1b[27m"
+	currentMatchOn  = "\x1b[7;4m"
+	currentMatchOff = "\x1b[27;24m"
+)
+
+// noteMatch is a match of the note search in the rendered note, with the byte
+// offsets of the match in the line with its escape sequences removed.
+type noteMatch struct {
+	line, start, end int
+}
+
+func (m *model) toNoteSearch() tea.Cmd {
+	m.noteSearching = true
+	m.noteSearchInput = textinput.New()
+	m.noteSearchInput.Prompt = "/"
+	m.noteSearchInput.SetValue(m.noteSearchQuery)
+	m.noteSearchInput.Focus()
+	return textinput.Blink
+}
+
+func noteSearchUpdate(msg tea.KeyMsg, m model) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.noteSearching = false
+		return m, nil
+	case key.Matches(msg, m.keys.Confirm):
+		m.noteSearching = false
+		m.noteSearchQuery = m.noteSearchInput.Value()
+		m.noteMatchIndex = -1
+		m.scrollToMatch = true
+		// Render the note again with the matches.
+		return noteUpdate(nil, m)
+	}
+	var cmd tea.Cmd
+	m.noteSearchInput, cmd = m.noteSearchInput.Update(msg)
+	return m, cmd
+}
+
+// nextMatch selects the next match after the current one, or the previous one
+// if step is negative.
+func (m *model) nextMatch(step int) {
+	if len(m.noteMatches) == 0 {
+		return
+	}
+	m.noteMatchIndex = (m.noteMatchIndex + step + len(m.noteMatches)) % len(m.noteMatches)
+	m.scrollToMatch = true
+}
+
+// highlightMatches finds the note search query in the rendered note and
+// returns it with the matches highlighted.
+func (m *model) highlightMatches(rendered string) string {
+	m.noteMatches = nil
+	if m.noteSearchQuery == "" {
+		return rendered
+	}
+
+	// The case is ignored by the regular expression instead of lowering the
+	// line, which can change the length of some characters and with it the
+	// offsets of the matches.
+	pattern := regexp.QuoteMeta(m.noteSearchQuery)
+	if strings.IndexFunc(m.noteSearchQuery, unicode.IsUpper) == -1 {
+		pattern = "(?i)" + pattern
+	}
+	re := regexp.MustCompile(pattern)
+
+	lines := strings.Split(rendered, "\n")
+	ranges := make([][][2]int, len(lines))
+	for i, line := range lines {
+		for _, loc := range re.FindAllStringIndex(stripANSI(line), -1) {
+			ranges[i] = append(ranges[i], [2]int{loc[0], loc[1]})
+			m.noteMatches = append(m.noteMatches, noteMatch{i, loc[0], loc[1]})
+		}
+	}
+
+	if m.noteMatchIndex >= len(m.noteMatches) {
+		m.noteMatchIndex = len(m.noteMatches) - 1
+	}
+	if m.noteMatchIndex == -1 && len(m.noteMatches) > 0 {
+		// Start from the first match on screen, like less.
+		m.noteMatchIndex = 0
+		for i, match := range m.noteMatches {
+			if match.line >= m.noteViewport.YOffset {
+				m.noteMatchIndex = i
+				break
+			}
+		}
+	}
+
+	for i, lineRanges := range ranges {
+		if len(lineRanges) > 0 {
+			lines[i] = m.highlightLine(lines[i], i, lineRanges)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// highlightLine highlights the ranges of the line number n of the rendered
+// note, given as offsets in the line without escape sequences. Escape sequences
+// inside a match could turn the highlight off, so it is turned on again after
+// them.
+func (m model) highlightLine(line string, n int, ranges [][2]int) string {
+	on, off := matchOn, matchOff
+	var b strings.Builder
+	plain := 0
+	r := 0
+	inMatch := false
+	for i := 0; i < len(line); {
+		if n := ansiLen(line[i:]); n > 0 {
+			b.WriteString(line[i : i+n])
+			if inMatch {
+				b.WriteString(on)
+			}
+			i += n
+			continue
+		}
+		if !inMatch && r < len(ranges) && plain == ranges[r][0] {
+			on, off = matchOn, matchOff
+			if m.isCurrentMatch(n, ranges[r][0]) {
+				on, off = currentMatchOn, currentMatchOff
+			}
+			b.WriteString(on)
+			inMatch = true
+		}
+		b.WriteByte(line[i])
+		i++
+		plain++
+		if inMatch && plain == ranges[r][1] {
+			b.WriteString(off)
+			inMatch = false
+			r++
+		}
+	}
+	if inMatch {
+		b.WriteString(off)
+	}
+	return b.String()
+}
+
+func (m model) isCurrentMatch(line int, start int) bool {
+	if m.noteMatchIndex < 0 || m.noteMatchIndex >= len(m.noteMatches) {
+		return false
+	}
+	match := m.noteMatches[m.noteMatchIndex]
+	return match.line == line && match.start == start
+}
+
+// scrollToCurrentMatch scrolls the note so the current match is visible.
+func (m *model) scrollToCurrentMatch() {
+	if m.noteMatchIndex < 0 || m.noteMatchIndex >= len(m.noteMatches) {
+		return
+	}
+	line := m.noteMatches[m.noteMatchIndex].line
+	top := m.noteViewport.YOffset
+	if line < top || line >= top+m.noteViewport.Height {
+		m.noteViewport.SetYOffset(line - m.noteViewport.Height/2)
+	}
+}
+
+func (m model) noteSearchStatus() string {
+	if len(m.noteMatches) == 0 {
+		return fmt.Sprintf("Pattern not found: %s", m.noteSearchQuery)
+	}
+	return fmt.Sprintf("Match %d of %d for %s", m.noteMatchIndex+1, len(m.noteMatches), m.noteSearchQuery)
+}
@@ -0,0 +1,223 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zd4y/enotes/enotes"
+)
+
+// outlineWidth is the width of the outline pane, including its border.
+const outlineWidth = 30
+
+// noteWidth returns the width left for the note by the outline pane.
+func (m model) noteWidth() int {
+	if m.showingOutline {
+		return max(m.width-outlineWidth, 1)
+	}
+	return m.width
+}
+
+func (m *model) toggleOutline() {
+	m.showingOutline = !m.showingOutline
+	if m.showingOutline {
+		m.outlineIndex = max(m.currentHeading(), 0)
+	}
+	m.noteViewport.Width = m.noteWidth()
+}
+
+// outlineUpdate handles the keys of the outline pane, reporting whether the
+// key was one of them.
+func outlineUpdate(msg tea.KeyMsg, m model) (model, bool) {
+	switch {
+	case key.Matches(msg, m.keys.Cancel, m.keys.Outline):
+		m.toggleOutline()
+	case key.Matches(msg, m.keys.Up):
+		if m.outlineIndex > 0 {
+			m.outlineIndex--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if m.outlineIndex < len(m.noteHeadings)-1 {
+			m.outlineIndex++
+		}
+	case key.Matches(msg, m.keys.Confirm):
+		m.scrollToHeading = true
+	case key.Matches(msg, m.keys.Toggle, m.keys.Fold):
+		m.toggleFold(m.outlineIndex)
+		m.scrollToHeading = true
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// toggleFold folds or unfolds the section of heading i, if it has any content.
+func (m *model) toggleFold(i int) {
+	if i < 0 || i >= len(m.noteHeadings) || !m.foldable(i) {
+		return
+	}
+	if m.foldedHeadings[i] {
+		delete(m.foldedHeadings, i)
+		return
+	}
+	if m.foldedHeadings == nil {
+		m.foldedHeadings = map[int]bool{}
+	}
+	m.foldedHeadings[i] = true
+}
+
+// toggleFoldAll folds every section, or unfolds them all if any is folded.
+func (m *model) toggleFoldAll() {
+	if len(m.foldedHeadings) > 0 {
+		m.foldedHeadings = nil
+		return
+	}
+	m.foldedHeadings = map[int]bool{}
+	for i := range m.noteHeadings {
+		if m.foldable(i) {
+			m.foldedHeadings[i] = true
+		}
+	}
+}
+
+func (m model) foldable(i int) bool {
+	h := m.noteHeadings[i]
+	return strings.TrimSpace(m.noteBody[h.End:h.SectionEnd]) != ""
+}
+
+// folds returns the folded sections that are not inside another folded
+// section, in order. Sections are not folded in the raw view.
+func (m model) folds() []enotes.Heading {
+	if m.rawView {
+		return nil
+	}
+	var folds []enotes.Heading
+	for i, h := range m.noteHeadings {
+		if !m.foldedHeadings[i] {
+			continue
+		}
+		if n := len(folds); n > 0 && h.Start < folds[n-1].SectionEnd {
+			continue
+		}
+		folds = append(folds, h)
+	}
+	return folds
+}
+
+// folded reports whether the byte offset i of the note body is hidden in a
+// folded section.
+func (m model) folded(i int) bool {
+	return inFolds(m.folds(), i)
+}
+
+func inFolds(folds []enotes.Heading, i int) bool {
+	for _, h := range folds {
+		if i >= h.End && i < h.SectionEnd {
+			return true
+		}
+	}
+	return false
+}
+
+// noteMarkdown returns the Markdown rendered in the note view: the body with
+// its wiki links highlighted, its secrets masked and the folded sections
+// replaced by the number of lines they hide.
+func (m model) noteMarkdown() string {
+	var b strings.Builder
+	last := 0
+	for _, h := range m.folds() {
+		b.WriteString(m.bodyMarkdown(last, h.End))
+		lines := strings.Count(strings.Trim(m.noteBody[h.End:h.SectionEnd], "\n"), "\n") + 1
+		if lines == 1 {
+			b.WriteString("\n*⋯ 1 line folded*\n\n")
+		} else {
+			fmt.Fprintf(&b, "\n*⋯ %d lines folded*\n\n", lines)
+		}
+		last = h.SectionEnd
+	}
+	b.WriteString(m.bodyMarkdown(last, len(m.noteBody)))
+	return b.String()
+}
+
+// findHeadingLines finds the line of every heading in the rendered note,
+// looking for their text in order. Headings in folded sections are at line -1.
+func (m *model) findHeadingLines(rendered string) {
+	lines := strings.Split(stripANSI(rendered), "\n")
+	m.headingLines = make([]int, len(m.noteHeadings))
+	folds := m.folds()
+	next := 0
+	for i, h := range m.noteHeadings {
+		m.headingLines[i] = -1
+		if inFolds(folds, h.Start) {
+			continue
+		}
+		text := []rune(strings.TrimSpace(h.Text))
+		// Long headings are wrapped, look for their beginning.
+		text = text[:min(len(text), 20)]
+		for j := next; j < len(lines); j++ {
+			if strings.Contains(lines[j], string(text)) {
+				m.headingLines[i] = j
+				next = j + 1
+				break
+			}
+		}
+	}
+}
+
+// currentHeading returns the heading of the section at the top of the note
+// view, or -1 if it is above the first heading.
+func (m model) currentHeading() int {
+	current := -1
+	for i, line := range m.headingLines {
+		if line != -1 && line <= m.noteViewport.YOffset {
+			current = i
+		}
+	}
+	return current
+}
+
+// scrollToSelectedHeading scrolls the note to the heading selected in the
+// outline.
+func (m *model) scrollToSelectedHeading() {
+	i := m.outlineIndex
+	if i >= len(m.headingLines) || m.headingLines[i] == -1 {
+		return
+	}
+	m.noteViewport.SetYOffset(m.headingLines[i])
+}
+
+// outlineView lists the headings of the note indented by level, with the
+// selected one highlighted and the folded ones marked.
+func (m model) outlineView() string {
+	height := m.noteViewport.Height
+	width := outlineWidth - outlineStyle.GetHorizontalFrameSize()
+
+	var lines []string
+	if len(m.noteHeadings) == 0 {
+		lines = append(lines, descStyle.Render("No headings"))
+	}
+	first := max(min(m.outlineIndex-height/2, len(m.noteHeadings)-height), 0)
+	for i := first; i < len(m.noteHeadings) && i < first+height; i++ {
+		h := m.noteHeadings[i]
+		marker := " "
+		if m.foldable(i) {
+			marker = "▾"
+			if m.foldedHeadings[i] {
+				marker = "▸"
+			}
+		}
+		line := []rune(strings.Repeat(" ", h.Level-1) + marker + " " + h.Text)
+		if len(line) > width {
+			line = append(line[:width-1], '…')
+		}
+		if i == m.outlineIndex {
+			lines = append(lines, selectedLinkStyle.Render(string(line)))
+		} else {
+			lines = append(lines, string(line))
+		}
+	}
+	style := outlineStyle.Width(outlineWidth - outlineStyle.GetHorizontalBorderSize())
+	return style.Height(height).Render(strings.Join(lines, "\n"))
+}
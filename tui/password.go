@@ -3,17 +3,17 @@ package tui
 import (
 	"fmt"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func passwordUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 	if msg, ok := msg.(tea.KeyMsg); ok {
-		msg := msg.String()
-		switch msg {
-		case "esc":
+		switch {
+		case key.Matches(msg, m.keys.Cancel):
 			m.quitting = true
 			return m, nil
-		case "enter":
+		case key.Matches(msg, m.keys.Confirm):
 			m.password = m.textInput.Value()
 			return m, verifyPassword(m.password)
 		}
@@ -28,6 +28,6 @@ func passwordView(m model) string {
 	return fmt.Sprintf(
 		"Password?\n\n%s\n\n%s\n",
 		m.textInput.View(),
-		"(esc to quit)",
+		fmt.Sprintf("(%s to quit)", m.keys.Cancel.Help().Key),
 	)
 }
@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The preview shows the selected note next to the notes list. Notes are only
+// decrypted once the cursor stays on them for previewDelay, so moving through
+// the list doesn't decrypt every note on the way, and the previews are kept
+// for the rest of the session. Notes that can't be decrypted are kept too, so
+// they are only tried again once they change.
+
+const (
+	previewDelay = 300 * time.Millisecond
+	// previewSize is how much of the body of a note is rendered, since the
+	// preview only shows its beginning.
+	previewSize = 4 << 10
+)
+
+// previewKey identifies a version of a note, so notes changed since they were
+// previewed are decrypted again.
+type previewKey struct {
+	path    string
+	modTime time.Time
+}
+
+type notePreview struct {
+	// body is the beginning of the note body, unless decrypting the note
+	// failed with err.
+	body      string
+	decrypted bool
+	width     int
+	rendered  string
+	err       error
+}
+
+func (m model) listWidth() int {
+	h, _ := docStyle.GetFrameSize()
+	if m.showingPreview {
+		return (m.windowWidth - h) / 2
+	}
+	return m.windowWidth - h
+}
+
+func (m model) previewWidth() int {
+	h, _ := docStyle.GetFrameSize()
+	return max(m.windowWidth-h-m.listWidth()-previewStyle.GetHorizontalFrameSize(), 1)
+}
+
+func (m *model) resizeList() {
+	_, v := docStyle.GetFrameSize()
+	m.list.SetSize(m.listWidth(), m.height-v)
+}
+
+func (m *model) togglePreview() tea.Cmd {
+	m.showingPreview = !m.showingPreview
+	m.previewKey = previewKey{}
+	m.resizeList()
+	return m.updatePreview()
+}
+
+// updatePreview starts the delay before previewing the selected note if it
+// changed, or renders it again if the preview width changed.
+func (m *model) updatePreview() tea.Cmd {
+	if !m.showingPreview {
+		return nil
+	}
+	item, ok := m.selectedNote()
+	if !ok {
+		m.previewKey = previewKey{}
+		return nil
+	}
+	key := previewKey{item.path, item.file.ModTime()}
+	preview, cached := m.previews[key]
+	if key == m.previewKey && (!cached || !preview.decrypted || preview.width == m.previewWidth()) {
+		return nil
+	}
+	m.previewKey = key
+	m.previewSeq++
+	if cached && !preview.decrypted {
+		return nil
+	}
+	if cached {
+		// Only the rendering is out of date.
+		return previewNote(key, preview, m.previewWidth(), m.password, m.theme)
+	}
+	seq := m.previewSeq
+	return tea.Tick(previewDelay, func(time.Time) tea.Msg {
+		return previewTickMsg{seq}
+	})
+}
+
+// previewUpdate handles the messages of the preview, which can arrive after
+// leaving the notes list.
+func previewUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case previewTickMsg:
+		if msg.seq == m.previewSeq && m.showingPreview {
+			return m, previewNote(m.previewKey, notePreview{}, m.previewWidth(), m.password, m.theme)
+		}
+	case previewMsg:
+		m.previews[msg.key] = msg.preview
+	}
+	return m, nil
+}
+
+// previewBody returns the lines of body that fit in previewSize.
+func previewBody(body string) string {
+	if len(body) <= previewSize {
+		return body
+	}
+	body = body[:previewSize]
+	if i := strings.LastIndexByte(body, '\n'); i != -1 {
+		body = body[:i]
+	}
+	return body
+}
+
+func (m model) previewView() string {
+	var content string
+	preview, ok := m.previews[m.previewKey]
+	switch {
+	case m.previewKey == (previewKey{}):
+		content = descStyle.Render("Select a note to preview it")
+	case !ok:
+		content = m.spinner.View() + " Decrypting note"
+	case preview.err != nil:
+		content = "Could not preview note: " + preview.err.Error()
+	default:
+		content = preview.rendered
+	}
+
+	_, v := docStyle.GetFrameSize()
+	height := max(m.height-v, 1)
+	lines := strings.Split(content, "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return previewStyle.
+		Width(m.previewWidth()).
+		MaxWidth(m.previewWidth() + previewStyle.GetHorizontalFrameSize()).
+		Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/formatters"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/muesli/reflow/wordwrap"
+	"github.com/muesli/reflow/wrap"
+)
+
+// rawNote returns the Markdown of a note highlighted with the chroma style
+// syntax, with line numbers and the lines longer than width wrapped.
+func rawNote(markdown string, width int, syntax string) (string, error) {
+	lexer := lexers.Get("markdown")
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	tokens, err := chroma.Tokenise(lexer, nil, markdown)
+	if err != nil {
+		return "", err
+	}
+	lines := chroma.SplitTokensIntoLines(tokens)
+
+	digits := len(strconv.Itoa(len(lines)))
+	gutter := digits + len(" │ ")
+	var b strings.Builder
+	for i, tokens := range lines {
+		// Lines are highlighted on their own so the colors of a token
+		// spanning several lines don't leak into the line numbers.
+		var line strings.Builder
+		if err := formatters.TTY256.Format(&line, styles.Get(syntax), chroma.Literator(tokens...)); err != nil {
+			return "", err
+		}
+		text := strings.ReplaceAll(line.String(), "\n", "")
+		limit := max(width-gutter, 1)
+		// Words longer than the limit are broken too.
+		wrapped := wrap.String(wordwrap.String(text, limit), limit)
+		for j, part := range strings.Split(wrapped, "\n") {
+			number := ""
+			if j == 0 {
+				number = strconv.Itoa(i + 1)
+			}
+			fmt.Fprintf(&b, "%s %s\n", lineNumberStyle.Render(fmt.Sprintf("%*s │", digits, number)), part)
+		}
+	}
+	return b.String(), nil
+}
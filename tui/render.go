@@ -0,0 +1,188 @@
+package tui
+
+import (
+	"crypto/sha256"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Rendering a note with glamour takes long for large notes, so it is done in
+// a command only when the Markdown shown or the width change, and the results
+// are kept to show them again without rendering, like when a section is
+// unfolded or a note is opened again. Notes can be megabytes long, so the
+// renderings are told apart by what the Markdown is made from instead of by
+// the Markdown itself.
+
+// renderCacheSize is the number of renderings kept.
+const renderCacheSize = 8
+
+type renderKey struct {
+	width int
+	// version is the version of the note contents.
+	version int
+	// raw is set to show the Markdown instead of rendering it.
+	raw bool
+	// folds and revealed are the indexes of the folded sections and of the
+	// revealed secrets, and link the selected link.
+	folds    string
+	revealed string
+	link     int
+}
+
+type renderCache struct {
+	rendered map[renderKey]string
+	// keys are the keys in rendered, least recently used first.
+	keys []renderKey
+	// versions are the versions of the note contents by their checksum.
+	versions map[[sha256.Size]byte]int
+}
+
+func newRenderCache() *renderCache {
+	return &renderCache{
+		rendered: map[renderKey]string{},
+		versions: map[[sha256.Size]byte]int{},
+	}
+}
+
+// version returns the version of the note contents, which is the same every
+// time the same contents are opened so their renderings are found again.
+func (c *renderCache) version(contents string) int {
+	sum := sha256.Sum256([]byte(contents))
+	v, ok := c.versions[sum]
+	if !ok {
+		v = len(c.versions) + 1
+		c.versions[sum] = v
+	}
+	return v
+}
+
+func (c *renderCache) get(key renderKey) (string, bool) {
+	out, ok := c.rendered[key]
+	if ok {
+		c.touch(key)
+	}
+	return out, ok
+}
+
+func (c *renderCache) add(key renderKey, out string) {
+	if _, ok := c.rendered[key]; !ok && len(c.keys) == renderCacheSize {
+		delete(c.rendered, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+	c.rendered[key] = out
+	c.touch(key)
+}
+
+// touch moves key to the end of the keys.
+func (c *renderCache) touch(key renderKey) {
+	for i, k := range c.keys {
+		if k == key {
+			c.keys = append(c.keys[:i], c.keys[i+1:]...)
+			break
+		}
+	}
+	c.keys = append(c.keys, key)
+}
+
+// noteContentKey is what the content of the note viewport depends on.
+type noteContentKey struct {
+	rendering  int
+	query      string
+	matchIndex int
+}
+
+// refreshNote renders the note again if its Markdown or the width changed,
+// and updates the note viewport with the latest rendering.
+func (m *model) refreshNote() tea.Cmd {
+	m.resizeNoteViewport()
+	key := m.noteRenderKey()
+	if key != m.renderKey {
+		m.renderKey = key
+		out, ok := m.renderCache.get(key)
+		if !ok {
+			return renderNote(key, m.renderedMarkdown(), m.theme)
+		}
+		m.setRenderedNote(out)
+	}
+	if m.renderedKey != m.renderKey {
+		// Scroll once the note is rendered.
+		return nil
+	}
+
+	contentKey := noteContentKey{m.renderings, m.noteSearchQuery, m.noteMatchIndex}
+	if contentKey != m.noteContentKey {
+		m.noteViewport.SetContent(m.highlightMatches(m.renderedNote))
+		m.noteContentKey = noteContentKey{m.renderings, m.noteSearchQuery, m.noteMatchIndex}
+	}
+	if m.scrollToLink {
+		m.scrollToLink = false
+		m.scrollToSelectedLink(m.renderedNote)
+	}
+	if m.scrollToMatch {
+		m.scrollToMatch = false
+		m.scrollToCurrentMatch()
+	}
+	if m.scrollToHeading {
+		m.scrollToHeading = false
+		m.scrollToSelectedHeading()
+	}
+	return nil
+}
+
+// noteRenderKey returns the key of the rendering of the note as it is shown.
+func (m model) noteRenderKey() renderKey {
+	key := renderKey{
+		width:    m.noteWidth(),
+		version:  m.noteVersion,
+		raw:      m.rawView,
+		revealed: indexSet(m.revealedSecrets),
+	}
+	if !m.rawView {
+		key.folds = indexSet(m.foldedHeadings)
+		key.link = m.linkIndex
+	}
+	return key
+}
+
+// renderedMarkdown returns the Markdown rendered in the note view, the
+// contents of the note in the raw view.
+func (m model) renderedMarkdown() string {
+	if m.rawView {
+		return m.rawMarkdown()
+	}
+	return m.noteMarkdown()
+}
+
+// indexSet returns the indexes in set, sorted and separated by commas.
+func indexSet(set map[int]bool) string {
+	var indexes []int
+	for i, ok := range set {
+		if ok {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Ints(indexes)
+	s := make([]string, len(indexes))
+	for i, index := range indexes {
+		s[i] = strconv.Itoa(index)
+	}
+	return strings.Join(s, ",")
+}
+
+func (m *model) setRenderedNote(out string) {
+	m.renderedNote = out
+	m.renderedKey = m.renderKey
+	m.renderings++
+	m.findHeadingLines(out)
+}
+
+// resetRenderedNote forgets the rendering of the current note before opening
+// another one.
+func (m *model) resetRenderedNote() {
+	m.renderKey = renderKey{}
+	m.renderedKey = renderKey{}
+	m.renderedNote = ""
+}
@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+)
+
+// largeNote returns a note body of about size bytes, with headings, lists,
+// code blocks and links like a real note.
+func largeNote(size int) string {
+	var b strings.Builder
+	for i := 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, "## Section %d\n\n", i)
+		b.WriteString("Some *emphasized* text with `code` and a [link](https://example.com), ")
+		b.WriteString("long enough to be wrapped by the renderer at the width of the terminal.\n\n")
+		b.WriteString("- first item\n- second item\n  - nested item\n\n")
+		b.WriteString("```go\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n```\n\n")
+	}
+	return b.String()
+}
+
+const largeNoteSize = 2 << 20
+
+func largeNoteModel(size int) model {
+	m := model{
+		chosen:       1,
+		width:        100,
+		height:       40,
+		noteViewport: viewport.New(100, 40),
+		renderCache:  newRenderCache(),
+		noteBody:     largeNote(size),
+		linkIndex:    -1,
+		keys:         defaultKeyMap(),
+		theme:        &theme{markdown: *glamour.DefaultStyles["dark"], syntax: "monokai"},
+	}
+	return m
+}
+
+// BenchmarkRenderNote measures rendering a large note, which was done on
+// every message the note view received.
+func BenchmarkRenderNote(b *testing.B) {
+	m := largeNoteModel(largeNoteSize)
+	key := m.noteRenderKey()
+	markdown := m.renderedMarkdown()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if msg := renderNote(key, markdown, m.theme)().(renderNoteMsg); msg.err != nil {
+			b.Fatal(msg.err)
+		}
+	}
+}
+
+// renderedLargeNote is the rendering of the large note used by benchmarks, so
+// it is rendered once for all of their runs.
+var renderedLargeNote struct {
+	sync.Once
+	msg renderNoteMsg
+}
+
+// BenchmarkNoteKeyPress measures handling a key press in the view of a large
+// note that is already rendered.
+func BenchmarkNoteKeyPress(b *testing.B) {
+	m := largeNoteModel(largeNoteSize)
+	m.renderKey = m.noteRenderKey()
+	renderedLargeNote.Do(func() {
+		renderedLargeNote.msg = renderNote(m.renderKey, m.renderedMarkdown(), m.theme)().(renderNoteMsg)
+	})
+	if err := renderedLargeNote.msg.err; err != nil {
+		b.Fatal(err)
+	}
+	m.setRenderedNote(renderedLargeNote.msg.out)
+
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		updated, _ := noteUpdate(down, m)
+		m = updated.(model)
+		if m.renderedKey != m.renderKey {
+			b.Fatal("the note was rendered again")
+		}
+	}
+}
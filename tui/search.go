@@ -0,0 +1,207 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zd4y/enotes/enotes"
+)
+
+const (
+	// searchResultsShown is the maximum number of results in the search screen.
+	searchResultsShown = 15
+	// searchLinesShown is the maximum number of matching lines shown for every
+	// note when grepping.
+	searchLinesShown = 2
+)
+
+// searchModes are the ways the search screen can find notes. The first one
+// uses the search index, the others decrypt the notes to grep them.
+var searchModes = []struct {
+	name        string
+	placeholder string
+	grep        enotes.GrepMode
+}{
+	{"words", "Words to search for", ""},
+	{"text", "Text to search for", enotes.GrepText},
+	{"regexp", "Regular expression to search for", enotes.GrepRegexp},
+	{"fuzzy", "Characters to search for", enotes.GrepFuzzy},
+}
+
+func (m *model) toSearch() tea.Cmd {
+	m.searching = true
+	m.searchInput = textinput.New()
+	m.searchInput.Placeholder = searchModes[m.searchMode].placeholder
+	m.searchInput.Focus()
+	m.searchResults = nil
+	m.grepResults = nil
+	m.searchErr = ""
+	m.searchIndex = 0
+	return textinput.Blink
+}
+
+func searchUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case searchMsg:
+		if msg.query != m.searchInput.Value() || msg.mode != m.searchMode {
+			// The query changed while searching.
+			return m, nil
+		}
+		m.searchErr = ""
+		if msg.err != nil {
+			if searchModes[msg.mode].grep == "" {
+				m.fail("search notes", "", msg.err)
+			} else {
+				// Most likely an invalid regular expression being typed.
+				m.searchErr = msg.err.Error()
+			}
+			return m, nil
+		}
+		m.searchResults = msg.results
+		m.grepResults = msg.grepResults
+		m.searchIndex = 0
+		return m, nil
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.keys.Cancel):
+			m.searching = false
+			return m, nil
+		case key.Matches(msg, m.keys.SearchMode):
+			m.searchMode = (m.searchMode + 1) % len(searchModes)
+			m.searchInput.Placeholder = searchModes[m.searchMode].placeholder
+			return m, m.search()
+		case key.Matches(msg, m.keys.PrevResult):
+			if m.searchIndex > 0 {
+				m.searchIndex--
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.NextResult):
+			if m.searchIndex < min(len(m.searchResultNames()), searchResultsShown)-1 {
+				m.searchIndex++
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Confirm):
+			if names := m.searchResultNames(); len(names) > 0 {
+				cmd := m.openNoteAt(enotes.NotePath(names[m.searchIndex]))
+				return m, cmd
+			}
+			return m, nil
+		}
+	}
+
+	query := m.searchInput.Value()
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	if m.searchInput.Value() != query {
+		return m, tea.Batch(cmd, m.search())
+	}
+	return m, cmd
+}
+
+// search runs the current query again, to show the results as it's typed or
+// after a note changed.
+func (m *model) search() tea.Cmd {
+	query := m.searchInput.Value()
+	if strings.TrimSpace(query) == "" {
+		m.searchResults = nil
+		m.grepResults = nil
+		m.searchErr = ""
+		return nil
+	}
+	return searchNotes(query, m.searchMode, searchModes[m.searchMode].grep, m.password)
+}
+
+// searchResultNames returns the names of the notes found by the last search.
+func (m model) searchResultNames() []string {
+	var names []string
+	for _, r := range m.searchResults {
+		names = append(names, r.Name)
+	}
+	for _, r := range m.grepResults {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func searchView(m model) string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Search"))
+	b.WriteString(" ")
+	for i, mode := range searchModes {
+		if i == m.searchMode {
+			b.WriteString(" " + bold.Render(mode.name))
+		} else {
+			b.WriteString(" " + descStyle.Render(mode.name))
+		}
+	}
+	b.WriteString("\n\n")
+	b.WriteString(m.searchInput.View())
+	b.WriteString("\n\n")
+
+	names := m.searchResultNames()
+	switch {
+	case m.searchErr != "":
+		b.WriteString(m.searchErr + "\n")
+	case m.searchResults != nil && len(names) == 0, m.grepResults != nil && len(names) == 0:
+		b.WriteString("No notes found\n")
+	}
+	for i, name := range names {
+		if i == searchResultsShown {
+			fmt.Fprintf(&b, "%s\n", descStyle.Render(fmt.Sprintf("and %d more", len(names)-i)))
+			break
+		}
+		line := name
+		if i < len(m.searchResults) {
+			if r := m.searchResults[i]; r.Title != "" && r.Title != r.Name {
+				line += " " + descStyle.Render(r.Title)
+			}
+		}
+		if i == m.searchIndex {
+			b.WriteString(bold.Render("> ") + line + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+		if i < len(m.grepResults) {
+			for j, match := range m.grepResults[i].Matches {
+				if j == searchLinesShown {
+					break
+				}
+				prefix := fmt.Sprintf("%4d ", match.Line)
+				text := highlightRanges(match.Text, match.Ranges, m.width-len(prefix)-4)
+				b.WriteString("  " + descStyle.Render(prefix) + text + "\n")
+			}
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(joinHelp(
+		bindingsHelp("select", m.keys.PrevResult, m.keys.NextResult),
+		bindingsHelp("open", m.keys.Confirm),
+		bindingsHelp("search mode", m.keys.SearchMode),
+		bindingsHelp("go back", m.keys.Cancel),
+	))
+	return docStyle.Render(b.String())
+}
+
+// highlightRanges renders the ranges of s with matchStyle, cutting s to at
+// most width runes.
+func highlightRanges(s string, ranges [][2]int, width int) string {
+	if width > 0 && len([]rune(s)) > width {
+		s = string([]rune(s)[:width])
+	}
+	var b strings.Builder
+	last := 0
+	for _, r := range ranges {
+		if r[0] >= len(s) {
+			break
+		}
+		end := min(r[1], len(s))
+		b.WriteString(s[last:r[0]])
+		b.WriteString(matchStyle.Render(s[r[0]:end]))
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
@@ -0,0 +1,200 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zd4y/enotes/enotes"
+)
+
+// mask replaces the values of secret fields. It doesn't depend on the value,
+// so its length isn't shown either.
+const mask = "••••••••"
+
+// secretsMarkdown returns the Markdown shown in place of a block of secret
+// fields, with the values masked unless revealed reports otherwise.
+func secretsMarkdown(block enotes.SecretBlock, revealed func(field int) bool) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	for i, field := range block.Fields {
+		value := mask
+		if revealed != nil && revealed(i) {
+			value = field.Value
+		}
+		fmt.Fprintf(&b, "- **%s**: `` %s ``\n", escapeMarkdown(field.Key), value)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// maskSecrets returns body with the values of its secret fields masked.
+func maskSecrets(body string) string {
+	var b strings.Builder
+	last := 0
+	for _, block := range enotes.SecretBlocks(body) {
+		b.WriteString(body[last:block.Start])
+		b.WriteString(secretsMarkdown(block, nil))
+		last = block.End
+	}
+	b.WriteString(body[last:])
+	return b.String()
+}
+
+// bodyMarkdown returns the note body from start to end with its wiki links
+// highlighted and its secret fields masked, except the ones revealed.
+func (m model) bodyMarkdown(start, end int) string {
+	var b strings.Builder
+	last := start
+	first := 0
+	for _, block := range m.noteSecrets {
+		offset := first
+		first += len(block.Fields)
+		if block.Start < start || block.End > end {
+			continue
+		}
+		b.WriteString(m.linkedBody(last, block.Start))
+		b.WriteString(secretsMarkdown(block, func(field int) bool {
+			return m.revealedSecrets[offset+field]
+		}))
+		last = block.End
+	}
+	b.WriteString(m.linkedBody(last, end))
+	return b.String()
+}
+
+// rawMarkdown returns the contents of the note shown in the raw view, with its
+// secret fields masked like in the rendered note.
+func (m model) rawMarkdown() string {
+	var b strings.Builder
+	body := m.noteBody
+	b.WriteString(m.noteContents[:len(m.noteContents)-len(body)])
+	last := 0
+	first := 0
+	for _, block := range m.noteSecrets {
+		offset := first
+		first += len(block.Fields)
+		b.WriteString(body[last:block.Start])
+		b.WriteString(rawSecrets(body[block.Start:block.End], func(field int) bool {
+			return m.revealedSecrets[offset+field]
+		}))
+		last = block.End
+	}
+	b.WriteString(body[last:])
+	return b.String()
+}
+
+// rawSecrets returns the source of a block of secret fields, fences included,
+// with the values masked unless revealed reports otherwise.
+func rawSecrets(source string, revealed func(field int) bool) string {
+	lines := strings.SplitAfter(source, "\n")
+	field := 0
+	// The first line is the opening fence, and the closing one has no colon.
+	for i := 1; i < len(lines); i++ {
+		key, value, ok := strings.Cut(lines[i], ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		if !revealed(field) {
+			lines[i] = key + ": " + mask
+			if strings.HasSuffix(value, "\n") {
+				lines[i] += "\n"
+			}
+		}
+		field++
+	}
+	return strings.Join(lines, "")
+}
+
+// secretFields returns the fields of every secret block in the note.
+func (m model) secretFields() []enotes.SecretField {
+	var fields []enotes.SecretField
+	for _, block := range m.noteSecrets {
+		fields = append(fields, block.Fields...)
+	}
+	return fields
+}
+
+func (m *model) toSecrets() {
+	m.showingSecrets = true
+	m.secretIndex = 0
+}
+
+func secretsUpdate(msg tea.KeyMsg, m model) (tea.Model, tea.Cmd) {
+	fields := m.secretFields()
+	switch {
+	case key.Matches(msg, m.keys.Back, m.keys.Secrets):
+		m.showingSecrets = false
+		// Show the note with the secrets revealed.
+		return noteUpdate(nil, m)
+	case key.Matches(msg, m.keys.Up):
+		if m.secretIndex > 0 {
+			m.secretIndex--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if m.secretIndex < len(fields)-1 {
+			m.secretIndex++
+		}
+	case key.Matches(msg, m.keys.Reveal):
+		if len(fields) > 0 {
+			if m.revealedSecrets[m.secretIndex] {
+				delete(m.revealedSecrets, m.secretIndex)
+			} else {
+				if m.revealedSecrets == nil {
+					m.revealedSecrets = map[int]bool{}
+				}
+				m.revealedSecrets[m.secretIndex] = true
+			}
+		}
+	case key.Matches(msg, m.keys.CopyValue):
+		if len(fields) > 0 {
+			field := fields[m.secretIndex]
+			return m, copyToClipboard(field.Value, field.Key)
+		}
+	}
+	return m, nil
+}
+
+// generatePassword copies a new password to the clipboard, generated with the
+// length and alphabet in the configuration.
+func (m model) generatePassword() tea.Cmd {
+	password, err := enotes.GeneratePassword(m.config.PasswordLength, m.config.PasswordAlphabet)
+	if err != nil {
+		return func() tea.Msg {
+			return clipboardMsg{err: fmt.Errorf("generate password: %w", err)}
+		}
+	}
+	return copyToClipboard(password, "generated password")
+}
+
+// secretsView lists the secret fields of the note, masked unless revealed, in
+// place of the note.
+func (m model) secretsView() string {
+	fields := m.secretFields()
+	width := m.noteWidth() - 2
+	var lines []string
+	if len(fields) == 0 {
+		lines = append(lines, descStyle.Render("No secret fields, add them in a ```secret code block"))
+	}
+	for i, field := range fields {
+		value := mask
+		if m.revealedSecrets[i] {
+			value = field.Value
+		}
+		line := []rune(fmt.Sprintf("%s: %s", field.Key, value))
+		if len(line) > width {
+			line = append(line[:width-1], '…')
+		}
+		if i == m.secretIndex {
+			lines = append(lines, bold.Render("> ")+selectedLinkStyle.Render(string(line)))
+		} else {
+			lines = append(lines, "  "+string(line))
+		}
+	}
+
+	first := max(min(m.secretIndex-m.noteViewport.Height/2, len(lines)-m.noteViewport.Height), 0)
+	lines = lines[first:min(len(lines), first+m.noteViewport.Height)]
+	return lipgloss.NewStyle().Height(m.noteViewport.Height).Render(strings.Join(lines, "\n"))
+}
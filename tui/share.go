@@ -0,0 +1,238 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (m *model) toShare() tea.Cmd {
+	m.shareRecipientInput = textinput.New()
+	m.shareRecipientInput.Placeholder = "age public key (age1...) or passphrase"
+	m.sharePathInput = textinput.New()
+	m.sharePathInput.Placeholder = "Output file (leave empty to show it here)"
+	if home, err := os.UserHomeDir(); err == nil {
+		m.sharePathInput.SetValue(filepath.Join(home, filepath.Base(m.noteName())+".age"))
+	}
+	m.shareFocus = 0
+	m.shareResult = ""
+	m.sharing = true
+	return m.shareRecipientInput.Focus()
+}
+
+func shareUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case shareMsg:
+		if msg.err != nil {
+			m.sharing = false
+			m.fail("share note", m.noteName(), msg.err)
+			return m, nil
+		}
+		if msg.path != "" {
+			m.shareResult = "Note written to " + msg.path
+		} else {
+			m.shareResult = msg.armored
+		}
+		return m, nil
+	case tea.KeyMsg:
+		if m.shareResult != "" {
+			if key.Matches(msg, m.keys.Back, m.keys.Confirm) {
+				m.sharing = false
+			}
+			return m, nil
+		}
+		switch {
+		case key.Matches(msg, m.keys.Cancel):
+			m.sharing = false
+			return m, nil
+		case key.Matches(msg, m.keys.NextField, m.keys.PrevField):
+			return m, m.toggleShareFocus()
+		case key.Matches(msg, m.keys.Confirm):
+			if m.shareFocus == 0 {
+				return m, m.toggleShareFocus()
+			}
+			recipient := m.shareRecipientInput.Value()
+			if recipient == "" {
+				m.sharing = false
+				m.fail("share note", m.noteName(), errors.New("no recipient or passphrase given"))
+				return m, nil
+			}
+			return m, shareNote(m.notePath, m.password, recipient, m.sharePathInput.Value())
+		}
+	}
+
+	var cmd tea.Cmd
+	if m.shareFocus == 0 {
+		m.shareRecipientInput, cmd = m.shareRecipientInput.Update(msg)
+		// Passphrases are hidden, public keys are not secret.
+		if strings.HasPrefix(m.shareRecipientInput.Value(), "age1") {
+			m.shareRecipientInput.EchoMode = textinput.EchoNormal
+		} else {
+			m.shareRecipientInput.EchoMode = textinput.EchoPassword
+		}
+	} else {
+		m.sharePathInput, cmd = m.sharePathInput.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m *model) toggleShareFocus() tea.Cmd {
+	if m.shareFocus == 0 {
+		m.shareFocus = 1
+		m.shareRecipientInput.Blur()
+		return m.sharePathInput.Focus()
+	}
+	m.shareFocus = 0
+	m.sharePathInput.Blur()
+	return m.shareRecipientInput.Focus()
+}
+
+func shareView(m model) string {
+	if m.shareResult != "" {
+		return fmt.Sprintf("%s\n\n%s\n", m.shareResult, m.dismissHelp())
+	}
+	return fmt.Sprintf(
+		"%s\n\nShare with: %s\n\nWrite to: %s\n\n%s\n",
+		titleStyle.Render("Share "+m.noteName()),
+		m.shareRecipientInput.View(),
+		m.sharePathInput.View(),
+		joinHelp(
+			bindingsHelp("switch fields", m.keys.NextField, m.keys.PrevField),
+			bindingsHelp("share", m.keys.Confirm),
+			bindingsHelp("cancel", m.keys.Cancel),
+		),
+	)
+}
+
+func (m *model) toReceive() tea.Cmd {
+	m.receiveArmored = nil
+	m.receiveKeyInput = textinput.New()
+	m.receiveKeyInput.Placeholder = "age secret key (AGE-SECRET-KEY-1...) or passphrase"
+	m.receiveKeyInput.EchoMode = textinput.EchoPassword
+	m.receiveNameInput = textinput.New()
+	m.receiveNameInput.Placeholder = "New note name"
+	m.receiveFocus = 0
+	m.receiving = true
+	return nil
+}
+
+func receiveUpdate(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case receiveMsg:
+		m.receiving = false
+		if msg.err != nil {
+			m.fail("import shared note", msg.name, msg.err)
+			return m, nil
+		}
+		return m, getDirFiles
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.keys.Cancel):
+			m.receiving = false
+			return m, nil
+		case key.Matches(msg, m.keys.NextField):
+			return m, m.focusReceiveField((m.receiveFocus + 1) % 3)
+		case key.Matches(msg, m.keys.PrevField):
+			return m, m.focusReceiveField((m.receiveFocus + 2) % 3)
+		case key.Matches(msg, m.keys.Confirm):
+			if m.receiveFocus == 0 {
+				break
+			}
+			if m.receiveFocus == 1 {
+				return m, m.focusReceiveField(2)
+			}
+			name := m.receiveNameInput.Value()
+			if name == "" {
+				return m, nil
+			}
+			return m, receiveNote(string(m.receiveArmored), m.receiveKeyInput.Value(), name, m.password)
+		}
+	}
+
+	var cmd tea.Cmd
+	switch m.receiveFocus {
+	case 0:
+		m.receiveArmored = updatePasteBuffer(m.receiveArmored, msg)
+	case 1:
+		m.receiveKeyInput, cmd = m.receiveKeyInput.Update(msg)
+	case 2:
+		m.receiveNameInput, cmd = m.receiveNameInput.Update(msg)
+	}
+	return m, cmd
+}
+
+// updatePasteBuffer appends the text pasted or typed in msg to buf. Pasted
+// text arrives as regular key presses, one line at a time.
+func updatePasteBuffer(buf []byte, msg tea.Msg) []byte {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return buf
+	}
+	switch key.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		return append(buf, string(key.Runes)...)
+	case tea.KeyEnter:
+		return append(buf, '\n')
+	case tea.KeyBackspace:
+		if len(buf) > 0 {
+			return buf[:len(buf)-1]
+		}
+	case tea.KeyCtrlU:
+		return buf[:0]
+	}
+	return buf
+}
+
+func (m *model) focusReceiveField(field int) tea.Cmd {
+	m.receiveKeyInput.Blur()
+	m.receiveNameInput.Blur()
+	m.receiveFocus = field
+	switch field {
+	case 0:
+		return nil
+	case 1:
+		return m.receiveKeyInput.Focus()
+	default:
+		return m.receiveNameInput.Focus()
+	}
+}
+
+func receiveView(m model) string {
+	armored := descStyle.Render("Paste the armored note (-----BEGIN AGE ENCRYPTED FILE-----)")
+	if len(m.receiveArmored) > 0 {
+		lines := strings.Split(strings.TrimRight(string(m.receiveArmored), "\n"), "\n")
+		armored = fmt.Sprintf("%s\n%s", lines[0], descStyle.Render(fmt.Sprintf("(%d lines)", len(lines))))
+		if len(lines) > 1 {
+			armored += "\n" + lines[len(lines)-1]
+		}
+	}
+	if m.receiveFocus == 0 {
+		armored = "> " + strings.ReplaceAll(armored, "\n", "\n  ")
+	} else {
+		armored = "  " + strings.ReplaceAll(armored, "\n", "\n  ")
+	}
+
+	// Confirming only imports from the name field.
+	importHelp := ""
+	if m.receiveFocus == 2 {
+		importHelp = bindingsHelp("import", m.keys.Confirm)
+	}
+	return fmt.Sprintf(
+		"%s\n\n%s\n\nKey: %s\n\nName: %s\n\n%s\n",
+		titleStyle.Render("Import shared note"),
+		armored,
+		m.receiveKeyInput.View(),
+		m.receiveNameInput.View(),
+		joinHelp(
+			bindingsHelp("switch fields", m.keys.NextField, m.keys.PrevField),
+			importHelp,
+			bindingsHelp("cancel", m.keys.Cancel),
+		),
+	)
+}
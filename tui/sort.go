@@ -0,0 +1,157 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zd4y/enotes/config"
+	"github.com/zd4y/enotes/enotes"
+)
+
+// The notes list is sorted by the field in the configuration, with the pinned
+// notes first. Creation times come from the search index, which can take a
+// while to build, so they are only loaded when sorting by them and until then
+// the notes are sorted by modification time instead.
+
+var sortFields = []string{"title", "modified", "created", "size"}
+
+type createdTimesMsg struct {
+	created map[string]time.Time
+	err     error
+}
+
+func loadCreatedTimes(password string) tea.Cmd {
+	return func() tea.Msg {
+		created, err := enotes.CreatedTimes(password)
+		return createdTimesMsg{created, err}
+	}
+}
+
+// pinMsg has the pinned notes after pinning or unpinning the note called
+// name, or when they are first loaded if name is empty.
+type pinMsg struct {
+	name   string
+	pinned []string
+	err    error
+}
+
+func loadPinnedNotes(password string) tea.Cmd {
+	return func() tea.Msg {
+		pinned, err := enotes.PinnedNotes(password)
+		return pinMsg{pinned: pinned, err: err}
+	}
+}
+
+func pinNote(name string, pinned bool, password string) tea.Cmd {
+	return func() tea.Msg {
+		notes, err := enotes.SetPinned(name, pinned, password)
+		return pinMsg{name, notes, err}
+	}
+}
+
+func (m *model) togglePin() tea.Cmd {
+	item, ok := m.selectedNote()
+	if !ok {
+		return nil
+	}
+	name := enotes.NoteName(item.path)
+	return pinNote(name, !m.pinned[name], m.password)
+}
+
+func (m *model) setPinned(names []string) {
+	m.pinned = make(map[string]bool, len(names))
+	for _, name := range names {
+		m.pinned[name] = true
+	}
+}
+
+// cycleSort sorts the notes by the next field, or in the opposite order if
+// reverse is set, and saves it in the configuration.
+func (m *model) cycleSort(reverse bool) tea.Cmd {
+	if reverse {
+		m.config.SortDescending = !m.config.SortDescending
+	} else {
+		m.config.Sort = sortFields[(sortFieldIndex(m.config.Sort)+1)%len(sortFields)]
+	}
+	if err := config.SaveSort(m.config); err != nil {
+		m.fail("save configuration", "", err)
+	}
+	order := "ascending"
+	if m.config.SortDescending {
+		order = "descending"
+	}
+	status := m.list.NewStatusMessage(fmt.Sprintf("Sorted by %s, %s", m.config.Sort, order))
+	cmds := []tea.Cmd{m.sortNotes(), status}
+	if m.config.Sort == "created" {
+		cmds = append(cmds, loadCreatedTimes(m.password))
+	}
+	return tea.Batch(cmds...)
+}
+
+func sortFieldIndex(field string) int {
+	for i, f := range sortFields {
+		if f == field {
+			return i
+		}
+	}
+	return 0
+}
+
+// sortNotes fills the notes list with m.notes in order, keeping the selected
+// note selected.
+func (m *model) sortNotes() tea.Cmd {
+	field := sortFields[sortFieldIndex(m.config.Sort)]
+	notes := make([]fileItem, len(m.notes))
+	for i, note := range m.notes {
+		note.pinned = m.pinned[enotes.NoteName(note.path)]
+		note.sortedBy = field
+		note.created = note.file.ModTime()
+		if created, ok := m.created[enotes.NoteName(note.path)]; ok {
+			note.created = created
+		}
+		notes[i] = note
+	}
+
+	less := func(a, b fileItem) bool {
+		switch field {
+		case "modified":
+			return a.file.ModTime().Before(b.file.ModTime())
+		case "created":
+			return a.created.Before(b.created)
+		case "size":
+			return a.file.Size() < b.file.Size()
+		}
+		return strings.ToLower(enotes.NoteName(a.path)) < strings.ToLower(enotes.NoteName(b.path))
+	}
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if a.pinned != b.pinned {
+			return a.pinned
+		}
+		if m.config.SortDescending {
+			a, b = b, a
+		}
+		if less(a, b) || less(b, a) {
+			return less(a, b)
+		}
+		return a.path < b.path
+	})
+
+	selected, _ := m.selectedNote()
+	items := []list.Item{newNoteItem}
+	for _, note := range notes {
+		items = append(items, note)
+	}
+	cmd := m.list.SetItems(items)
+	if index := noteIndex(items, m.notePath); index != -1 && m.inNote() {
+		m.chosen = index
+	}
+	if index := noteIndex(items, selected.path); index != -1 && m.list.FilterState() == list.Unfiltered {
+		m.list.Select(index)
+	}
+	return cmd
+}
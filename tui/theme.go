@@ -0,0 +1,269 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zd4y/enotes/config"
+)
+
+// Themes set the colors of the interface, the glamour style of rendered notes
+// and the chroma style of the raw view. The "auto" theme, the default, is the
+// dark or light one depending on the background of the terminal, which is
+// asked to the terminal before the interface starts.
+
+// themeColors are the colors of the interface, by their role.
+type themeColors struct {
+	// Title and TitleText are the background and foreground of titles.
+	Title     lipgloss.Color
+	TitleText lipgloss.Color
+	// Accent marks the selected note in the list.
+	Accent lipgloss.Color
+	// Link and Selection are the foreground and background of the selected
+	// item of the note view and its pickers.
+	Link      lipgloss.Color
+	Selection lipgloss.Color
+	// Key and Desc are used for the help of the keys, Desc for other
+	// secondary text too.
+	Key    lipgloss.Color
+	Desc   lipgloss.Color
+	Border lipgloss.Color
+	// Match highlights the matches of the search screen. The matches of
+	// the search in the note are in reverse video instead, to keep the
+	// colors of the note.
+	Match     lipgloss.Color
+	Error     lipgloss.Color
+	ErrorText lipgloss.Color
+}
+
+// named returns the colors of c by their names in the configuration.
+func (c *themeColors) named() map[string]*lipgloss.Color {
+	return map[string]*lipgloss.Color{
+		"title":     &c.Title,
+		"titleText": &c.TitleText,
+		"accent":    &c.Accent,
+		"link":      &c.Link,
+		"selection": &c.Selection,
+		"key":       &c.Key,
+		"desc":      &c.Desc,
+		"border":    &c.Border,
+		"match":     &c.Match,
+		"error":     &c.Error,
+		"errorText": &c.ErrorText,
+	}
+}
+
+type builtinTheme struct {
+	colors themeColors
+	// markdown is the name of a glamour standard style.
+	markdown string
+	// syntax is the name of a chroma style.
+	syntax string
+}
+
+var builtinThemes = map[string]builtinTheme{
+	"dark": {
+		colors: themeColors{
+			Title:     "62",
+			TitleText: "230",
+			Accent:    "#EE6FF8",
+			Link:      "203",
+			Selection: "236",
+			Key:       "#626262",
+			Desc:      "#4A4A4A",
+			Border:    "#3C3C3C",
+			Match:     "203",
+			Error:     "160",
+			ErrorText: "230",
+		},
+		markdown: "dark",
+		syntax:   "monokai",
+	},
+	"light": {
+		colors: themeColors{
+			Title:     "62",
+			TitleText: "230",
+			Accent:    "#EE6FF8",
+			Link:      "161",
+			Selection: "254",
+			Key:       "#909090",
+			Desc:      "#A0A0A0",
+			Border:    "#DDDADA",
+			Match:     "161",
+			Error:     "160",
+			ErrorText: "230",
+		},
+		markdown: "light",
+		syntax:   "github",
+	},
+	"dracula": {
+		colors: themeColors{
+			Title:     "#BD93F9",
+			TitleText: "#282A36",
+			Accent:    "#FF79C6",
+			Link:      "#8BE9FD",
+			Selection: "#44475A",
+			Key:       "#6272A4",
+			Desc:      "#525C86",
+			Border:    "#44475A",
+			Match:     "#F1FA8C",
+			Error:     "#FF5555",
+			ErrorText: "#F8F8F2",
+		},
+		markdown: "dracula",
+		syntax:   "dracula",
+	},
+}
+
+type theme struct {
+	colors   themeColors
+	markdown ansi.StyleConfig
+	syntax   string
+}
+
+// loadTheme returns the theme in the configuration, with its markdown style
+// and colors replaced by the ones configured.
+func loadTheme(cfg *config.Config) (*theme, error) {
+	name := cfg.Theme
+	if name == "" || name == "auto" {
+		name = "light"
+		if lipgloss.HasDarkBackground() {
+			name = "dark"
+		}
+	}
+	builtin, ok := builtinThemes[name]
+	if !ok {
+		var names []string
+		for name := range builtinThemes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown theme %q, it must be auto, %s", cfg.Theme, strings.Join(names, ", "))
+	}
+	t := &theme{
+		colors:   builtin.colors,
+		markdown: *glamour.DefaultStyles[builtin.markdown],
+		syntax:   builtin.syntax,
+	}
+
+	if style := cfg.MarkdownStyle; style != "" {
+		markdown, err := loadMarkdownStyle(style)
+		if err != nil {
+			return nil, err
+		}
+		t.markdown = *markdown
+	}
+
+	named := t.colors.named()
+	var unknown []string
+	for name, color := range cfg.Colors {
+		c, ok := named[name]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		*c = lipgloss.Color(color)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown colors in the configuration: %s", strings.Join(unknown, ", "))
+	}
+	return t, nil
+}
+
+// loadMarkdownStyle returns the glamour standard style named style, or the
+// one in the JSON file at that path.
+func loadMarkdownStyle(style string) (*ansi.StyleConfig, error) {
+	if markdown, ok := glamour.DefaultStyles[style]; ok {
+		return markdown, nil
+	}
+	data, err := os.ReadFile(style)
+	if err != nil {
+		return nil, fmt.Errorf("markdown style: %w", err)
+	}
+	var markdown ansi.StyleConfig
+	if err := json.Unmarshal(data, &markdown); err != nil {
+		return nil, fmt.Errorf("markdown style %s: %w", style, err)
+	}
+	return &markdown, nil
+}
+
+// The styles of the interface, set from the theme when enotes starts.
+var (
+	bold              = lipgloss.NewStyle().Bold(true)
+	titleStyle        lipgloss.Style
+	keyStyle          lipgloss.Style
+	descStyle         lipgloss.Style
+	sepStyle          lipgloss.Style
+	dot               string
+	selectedLinkStyle lipgloss.Style
+	matchStyle        lipgloss.Style
+	errorTitleStyle   lipgloss.Style
+	outlineStyle      lipgloss.Style
+	previewStyle      lipgloss.Style
+	lineNumberStyle   lipgloss.Style
+)
+
+func init() {
+	setStyles(builtinThemes["dark"].colors)
+}
+
+func setStyles(c themeColors) {
+	titleStyle = lipgloss.NewStyle().
+		Background(c.Title).
+		Foreground(c.TitleText).
+		Padding(0, 1)
+	keyStyle = lipgloss.NewStyle().Foreground(c.Key)
+	descStyle = lipgloss.NewStyle().Foreground(c.Desc)
+	sepStyle = lipgloss.NewStyle().Foreground(c.Border)
+	dot = sepStyle.Render(" • ")
+	selectedLinkStyle = lipgloss.NewStyle().
+		Foreground(c.Link).
+		Background(c.Selection)
+	matchStyle = lipgloss.NewStyle().Foreground(c.Match).Bold(true)
+	errorTitleStyle = lipgloss.NewStyle().
+		Background(c.Error).
+		Foreground(c.ErrorText).
+		Padding(0, 1)
+	outlineStyle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(c.Border).
+		PaddingLeft(1)
+	previewStyle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(c.Border).
+		MarginTop(1)
+	lineNumberStyle = lipgloss.NewStyle().Foreground(c.Desc)
+}
+
+// apply sets the styles of the interface and of l, the notes list, from t.
+func (t *theme) apply(l *list.Model) {
+	setStyles(t.colors)
+
+	l.Styles.Title = l.Styles.Title.Copy().
+		Background(t.colors.Title).
+		Foreground(t.colors.TitleText)
+	l.Styles.FilterCursor = l.Styles.FilterCursor.Copy().Foreground(t.colors.Accent)
+	l.Help.Styles.ShortKey = keyStyle
+	l.Help.Styles.ShortDesc = descStyle
+	l.Help.Styles.ShortSeparator = sepStyle
+	l.Help.Styles.FullKey = keyStyle
+	l.Help.Styles.FullDesc = descStyle
+	l.Help.Styles.FullSeparator = sepStyle
+
+	delegate := list.NewDefaultDelegate()
+	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Copy().
+		Foreground(t.colors.Accent).
+		BorderForeground(t.colors.Accent)
+	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.Copy().
+		Foreground(t.colors.Accent).
+		BorderForeground(t.colors.Accent)
+	l.SetDelegate(delegate)
+}
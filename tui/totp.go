@@ -0,0 +1,63 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The codes of the one-time passwords in a note are shown below it and
+// updated every second while the note is open.
+
+type totpTickMsg struct {
+	seq int
+}
+
+func tickTOTP(seq int) tea.Cmd {
+	return tea.Every(time.Second, func(time.Time) tea.Msg {
+		return totpTickMsg{seq}
+	})
+}
+
+// startTOTP starts updating the codes of the note, stopping the updates of
+// the previous one.
+func (m *model) startTOTP() tea.Cmd {
+	m.totpSeq++
+	if len(m.noteTOTPs) == 0 {
+		return nil
+	}
+	return tickTOTP(m.totpSeq)
+}
+
+func totpUpdate(msg totpTickMsg, m model) (tea.Model, tea.Cmd) {
+	if msg.seq != m.totpSeq || !m.inNote() || len(m.noteTOTPs) == 0 {
+		return m, nil
+	}
+	return m, tickTOTP(m.totpSeq)
+}
+
+func (m model) totpView() string {
+	if len(m.noteTOTPs) == 0 && len(m.noteTOTPErrs) == 0 {
+		return ""
+	}
+	now := time.Now()
+	var lines []string
+	for _, totp := range m.noteTOTPs {
+		code, remaining := totp.Code(now)
+		if len(code) == 6 {
+			code = code[:3] + " " + code[3:]
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s %s",
+			descStyle.Render("TOTP"),
+			bold.Render(totp.Name(m.noteName())),
+			code,
+			descStyle.Render(fmt.Sprintf("%2ds", int(remaining.Seconds())))))
+	}
+	for _, err := range m.noteTOTPErrs {
+		lines = append(lines, descStyle.Render(err.Error()))
+	}
+	return lipgloss.NewStyle().Width(m.width).Render(strings.Join(lines, "\n"))
+}